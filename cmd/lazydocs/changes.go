@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+func changesCommand() *command {
+	cmd := newCommand("changes", "<docset-a> [docset-b]", "List symbols added, removed or changed between two docsets", 1, 2)
+	cmd.help = "With one docset, compares it with the install its last update replaced."
+	cmd.complete = func(args []string, partial string) []string {
+		if len(args) >= 2 {
+			return nil
+		}
+		return installedSlugs()
+	}
+	cmd.flagValues = map[string][]string{"format": {"text", "markdown", "json"}}
+	format := cmd.flags.String("format", "text", "Output format: text, markdown or json")
+	cmd.run = func(args []string) error {
+		if globals.json {
+			*format = "json"
+		}
+		switch *format {
+		case "text", "markdown", "md", "json":
+		default:
+			return usageError{fmt.Sprintf("unknown format %q", *format)}
+		}
+
+		application, err := openLocalApp("changes")
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		var changes *model.Changelog
+		if len(args) == 1 {
+			changes, err = application.ChangesSinceUpdate(args[0])
+		} else {
+			changes, err = application.Changes(args[0], args[1])
+		}
+		if err != nil {
+			return err
+		}
+
+		switch *format {
+		case "markdown", "md":
+			writeChangesMarkdown(os.Stdout, changes)
+		case "json":
+			return writeJSON(changes)
+		default:
+			writeChangesText(os.Stdout, changes)
+		}
+		return nil
+	}
+	return cmd
+}
+
+// changeGroup is one section of a changelog report
+type changeGroup struct {
+	title   string
+	symbols []model.SymbolChange
+}
+
+// changeGroups returns the sections of a changelog in report order
+func changeGroups(c *model.Changelog) []changeGroup {
+	return []changeGroup{
+		{"Added", c.Added},
+		{"Removed", c.Removed},
+		{"Changed", c.Changed},
+	}
+}
+
+func writeChangesText(w io.Writer, c *model.Changelog) {
+	fmt.Fprintf(w, "Changes from %s to %s\n", c.From, c.To)
+	if c.Empty() {
+		fmt.Fprintln(w, "\nNo symbols changed")
+		return
+	}
+
+	for _, g := range changeGroups(c) {
+		if len(g.symbols) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s (%d):\n", g.title, len(g.symbols))
+		for _, s := range g.symbols {
+			fmt.Fprintf(w, "  %-40s %s\n", s.Symbol, s.Type)
+		}
+	}
+}
+
+func writeChangesMarkdown(w io.Writer, c *model.Changelog) {
+	fmt.Fprintf(w, "## Changes from `%s` to `%s`\n", c.From, c.To)
+	if c.Empty() {
+		fmt.Fprintln(w, "\nNo symbols changed.")
+		return
+	}
+
+	for _, g := range changeGroups(c) {
+		if len(g.symbols) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n### %s (%d)\n\n", g.title, len(g.symbols))
+		for _, s := range g.symbols {
+			if s.Type != "" {
+				fmt.Fprintf(w, "- `%s` (%s)\n", s.Symbol, s.Type)
+			} else {
+				fmt.Fprintf(w, "- `%s`\n", s.Symbol)
+			}
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/fuzzy"
+)
+
+// Exit codes shared by every command
+const (
+	exitOK      = 0
+	exitError   = 1 // The command failed
+	exitUsage   = 2 // The command line was wrong
+	exitNoMatch = 3 // A lookup found nothing, like grep's 1, or no single entry
+)
+
+// errNoMatch is returned by commands that found nothing to print, or
+// several entries where one was asked for. It exits with exitNoMatch and no
+// error message, since the command reported it.
+var errNoMatch = errors.New("no matches")
+
+// errUsage is returned for a command line mistake that has already been
+// reported along with the command's help
+var errUsage = errors.New("usage error")
+
+// errReported is returned for a failure already reported on stdout, like
+// a --json result. It exits with exitError and no further message.
+var errReported = errors.New("already reported")
+
+// usageError is a command line mistake that still needs reporting
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+// command is a lazydocs subcommand
+type command struct {
+	name    string
+	aliases []string
+	args    string // Argument synopsis, e.g. "<docset>"
+	summary string // One line for the command list
+	help    string // Longer description for "lazydocs help <command>"
+	hidden  bool   // Left out of the command list and completions
+
+	// Positional argument bounds; maxArgs < 0 means unlimited
+	minArgs, maxArgs int
+
+	// rawArgs passes the arguments to run without parsing flags
+	rawArgs bool
+
+	// complete returns shell completions for the positional argument after
+	// args, which starts with partial; nil when there are none to offer
+	complete func(args []string, partial string) []string
+
+	// flagValues lists the values of flags that take a fixed set, for
+	// shell completion
+	flagValues map[string][]string
+
+	flags *flag.FlagSet
+	run   func(args []string) error
+}
+
+// newCommand creates a command with an empty flag set. Callers register
+// their flags on cmd.flags and set cmd.run.
+func newCommand(name, args, summary string, minArgs, maxArgs int) *command {
+	cmd := &command{
+		name:    name,
+		args:    args,
+		summary: summary,
+		minArgs: minArgs,
+		maxArgs: maxArgs,
+		flags:   flag.NewFlagSet(name, flag.ContinueOnError),
+	}
+	cmd.flags.Usage = func() { cmd.printHelp(cmd.flags.Output()) }
+	return cmd
+}
+
+// commands returns the command tree in the order help lists it
+func commands() []*command {
+	return []*command{
+		installCommand(),
+		removeCommand(),
+		updateCommand(),
+		outdatedCommand(),
+		listCommand(),
+		syncCommand(),
+		detectCommand(),
+		searchCommand(),
+		queryCommand(),
+		showCommand(),
+		diffCommand(),
+		changesCommand(),
+		serveCommand(),
+		rpcCommand(),
+		configCommand(),
+		completionCommand(),
+		completeCommand(),
+		helpCommand(),
+		versionCommand(),
+	}
+}
+
+// findCommand returns the command called name, or nil
+func findCommand(name string) *command {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// commandNames returns the names of the visible commands
+func commandNames() []string {
+	var names []string
+	for _, cmd := range commands() {
+		if !cmd.hidden {
+			names = append(names, cmd.name)
+		}
+	}
+	return names
+}
+
+// suggestCommand returns the visible command whose name is closest to a
+// mistyped one, or "" when none is close enough to be a typo
+func suggestCommand(name string) string {
+	if len(name) < 3 {
+		return ""
+	}
+
+	best, bestDist := "", 3
+	for _, cmd := range commands() {
+		if cmd.hidden {
+			continue
+		}
+		for _, candidate := range append([]string{cmd.name}, cmd.aliases...) {
+			if dist := fuzzy.EditDistance(name, candidate); dist < bestDist {
+				best, bestDist = cmd.name, dist
+			}
+		}
+	}
+	return best
+}
+
+// execute parses the command's flags, which may appear anywhere among its
+// arguments, checks the arity and runs it
+func (cmd *command) execute(args []string) error {
+	if cmd.rawArgs {
+		return cmd.run(args)
+	}
+	registerGlobalFlags(cmd.flags)
+
+	positional, err := parseArgs(cmd.flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < cmd.minArgs || (cmd.maxArgs >= 0 && len(positional) > cmd.maxArgs) {
+		fmt.Fprintf(os.Stderr, "Error: %s: wrong number of arguments\n\n", cmd.name)
+		cmd.printHelp(os.Stderr)
+		return errUsage
+	}
+	return cmd.run(positional)
+}
+
+// printHelp writes the command's usage, description and own flags
+func (cmd *command) printHelp(w io.Writer) {
+	fmt.Fprintf(w, "Usage: lazydocs %s", cmd.name)
+	if hasFlags(cmd.flags) {
+		fmt.Fprint(w, " [flags]")
+	}
+	if cmd.args != "" {
+		fmt.Fprintf(w, " %s", cmd.args)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "\n%s\n", cmd.summary)
+	if cmd.help != "" {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(cmd.help))
+	}
+	if len(cmd.aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(cmd.aliases, ", "))
+	}
+	if hasFlags(cmd.flags) {
+		fmt.Fprintln(w, "\nFlags:")
+		printFlags(w, cmd.flags)
+	}
+	fmt.Fprintln(w, "\nRun 'lazydocs help' for global flags.")
+}
+
+// hasFlags reports whether fs has flags of its own, besides the global ones
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(f *flag.Flag) {
+		found = found || !isGlobalFlag(f.Name)
+	})
+	return found
+}
+
+// printFlags lists the flags of fs, skipping the global ones, in the
+// layout of flag.PrintDefaults but with double dashes
+func printFlags(w io.Writer, fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		if isGlobalFlag(f.Name) {
+			return
+		}
+		printFlag(w, f)
+	})
+}
+
+func printFlag(w io.Writer, f *flag.Flag) {
+	name, usage := flag.UnquoteUsage(f)
+	line := "  --" + f.Name
+	if name != "" {
+		line += " " + name
+	}
+	if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+		usage += fmt.Sprintf(" (default %s)", f.DefValue)
+	}
+	fmt.Fprintf(w, "%-24s %s\n", line, usage)
+}
+
+func helpCommand() *command {
+	cmd := newCommand("help", "[command]", "Show help for lazydocs or a command", 0, 1)
+	cmd.complete = func(args []string, partial string) []string {
+		if len(args) > 0 {
+			return nil
+		}
+		return commandNames()
+	}
+	cmd.run = func(args []string) error {
+		if len(args) == 0 {
+			printHelp(os.Stdout)
+			return nil
+		}
+		target := findCommand(args[0])
+		if target == nil {
+			return unknownCommand(args[0])
+		}
+		target.printHelp(os.Stdout)
+		return nil
+	}
+	return cmd
+}
+
+func versionCommand() *command {
+	cmd := newCommand("version", "", "Show the lazydocs version", 0, 0)
+	cmd.run = func(args []string) error {
+		fmt.Printf("lazydocs %s\n", version)
+		return nil
+	}
+	return cmd
+}
+
+// unknownCommand is the error for a name that isn't a command
+func unknownCommand(name string) error {
+	msg := fmt.Sprintf("unknown command %q", name)
+	if s := suggestCommand(name); s != "" {
+		msg += fmt.Sprintf("; did you mean %q?", s)
+	}
+	return usageError{msg}
+}
+
+// printHelp writes the top-level help
+func printHelp(w io.Writer) {
+	fmt.Fprint(w, `LazyDocs - Lazygit-style TUI for browsing DevDocs documentation
+
+Usage:
+  lazydocs [global flags]                    Open the TUI
+  lazydocs [global flags] <symbol>           Open TUI and search for symbol
+  lazydocs [global flags] <command> [args]
+
+Commands:
+`)
+	for _, cmd := range commands() {
+		if cmd.hidden {
+			continue
+		}
+		fmt.Fprintf(w, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintln(w, "\nGlobal flags:")
+	fs := flag.NewFlagSet("lazydocs", flag.ContinueOnError)
+	registerGlobalFlags(fs)
+	fs.VisitAll(func(f *flag.Flag) {
+		// Short aliases are mentioned in their long flag's usage
+		if len(f.Name) > 1 {
+			printFlag(w, f)
+		}
+	})
+	fmt.Fprintf(w, "%-24s %s\n", "  --lookup, -l symbol", "Same as lazydocs <symbol>")
+	fmt.Fprintf(w, "%-24s %s\n", "  --version, -v", "Show version")
+	fmt.Fprintf(w, "%-24s %s\n", "  --help, -h", "Show this help")
+
+	fmt.Fprint(w, `
+Exit codes: 0 success, 1 failure, 2 usage error, 3 no matches.
+
+Examples:
+  lazydocs install javascript
+  lazydocs install python~3.12
+  lazydocs search python        Search available Python docsets
+  lazydocs --json outdated
+  lazydocs query --docset go http handler --format tsv
+  lazydocs show --pager ruby~3.3 Array
+  lazydocs diff rails~7.1 rails~8.0 ActiveRecord::Base
+  lazydocs changes --format markdown python~3.11 python~3.12
+  lazydocs help query           Show the flags of a command
+
+Popular docsets: javascript, go, python~3.12, ruby~3.3, react, vue~3, rails~8.0
+`)
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/app"
+	"github.com/lazydocs/lazydocs/internal/config"
+)
+
+// maxSymbolCompletions caps symbol candidates so huge docsets stay fast
+const maxSymbolCompletions = 200
+
+func completionCommand() *command {
+	cmd := newCommand("completion", "<bash|zsh|fish>", "Print a shell completion script", 1, 1)
+	cmd.help = `Load it from your shell's startup file:
+
+  bash:  source <(lazydocs completion bash)
+  zsh:   source <(lazydocs completion zsh)
+  fish:  lazydocs completion fish | source`
+	cmd.complete = func(args []string, partial string) []string {
+		if len(args) > 0 {
+			return nil
+		}
+		return []string{"bash", "zsh", "fish"}
+	}
+	cmd.run = func(args []string) error {
+		script, ok := completionScripts[args[0]]
+		if !ok {
+			return usageError{fmt.Sprintf("unsupported shell %q; use bash, zsh or fish", args[0])}
+		}
+		fmt.Print(script)
+		return nil
+	}
+	return cmd
+}
+
+// completeCommand is the hidden entry point of the completion scripts. It
+// takes the words after "lazydocs", the last one being the word under the
+// cursor, and prints a candidate per line.
+func completeCommand() *command {
+	cmd := newCommand("__complete", "<words...>", "Print shell completions for the words typed so far", 0, -1)
+	cmd.hidden = true
+	cmd.rawArgs = true
+	cmd.run = func(args []string) error {
+		for _, c := range complete(args) {
+			fmt.Println(c)
+		}
+		return nil
+	}
+	return cmd
+}
+
+// complete returns the candidates for the last of words
+func complete(words []string) []string {
+	partial := ""
+	if len(words) > 0 {
+		partial, words = words[len(words)-1], words[:len(words)-1]
+	}
+
+	var cmd *command
+	var args []string
+	fs := flagSetFor(nil)
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if !strings.HasPrefix(w, "-") || w == "-" {
+			if cmd == nil {
+				if cmd = findCommand(w); cmd == nil {
+					return nil // A TUI lookup
+				}
+				fs = flagSetFor(cmd)
+				continue
+			}
+			args = append(args, w)
+			continue
+		}
+		if w == "--" {
+			continue
+		}
+		// Record --data-dir and --config so completions read the right index
+		name, value, hasValue := strings.Cut(strings.TrimLeft(w, "-"), "=")
+		if !hasValue && takesValue(fs, name) && i+1 < len(words) {
+			i++
+			value, hasValue = words[i], true
+		}
+		if hasValue && isGlobalFlag(name) {
+			fs.Set(name, value)
+		}
+	}
+
+	var candidates []string
+	if len(words) > 0 && strings.HasPrefix(words[len(words)-1], "-") {
+		prev := strings.TrimLeft(words[len(words)-1], "-")
+		if !strings.Contains(prev, "=") && takesValue(fs, prev) {
+			return filterPrefix(flagValueCompletions(cmd, prev), partial)
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(partial, "-"):
+		fs.VisitAll(func(f *flag.Flag) {
+			if len(f.Name) > 1 {
+				candidates = append(candidates, "--"+f.Name)
+			}
+		})
+	case cmd == nil:
+		candidates = commandNames()
+	case cmd.complete != nil:
+		candidates = cmd.complete(args, partial)
+	}
+	return filterPrefix(candidates, partial)
+}
+
+// flagSetFor returns the flags accepted after cmd, or before any command
+// when cmd is nil
+func flagSetFor(cmd *command) *flag.FlagSet {
+	fs := flag.NewFlagSet("lazydocs", flag.ContinueOnError)
+	if cmd != nil {
+		fs = cmd.flags
+	}
+	registerGlobalFlags(fs)
+	return fs
+}
+
+// takesValue reports whether the flag called name in fs needs a value
+func takesValue(fs *flag.FlagSet, name string) bool {
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return !ok || !b.IsBoolFlag()
+}
+
+// flagValueCompletions returns the candidates for the value of a flag
+func flagValueCompletions(cmd *command, name string) []string {
+	if name == "docset" {
+		return installedSlugs()
+	}
+	if cmd != nil {
+		return cmd.flagValues[name]
+	}
+	return nil
+}
+
+// filterPrefix keeps the candidates that start with prefix
+func filterPrefix(candidates []string, prefix string) []string {
+	var kept []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// completionBackend opens the index for completion: the server named by
+// --remote or remote.url if it answers, or else the local index read-only.
+// Unlike openBackend it never migrates or rebuilds the index, which could
+// take far longer than a key press allows.
+func completionBackend() (app.Backend, error) {
+	paths := config.NewPaths(globals.dataDir, globals.configPath)
+	cfg, err := config.Load(paths.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	url := globals.remote
+	if url == "" {
+		url = cfg.Remote.URL
+	}
+	if url != "" && url != localRemote {
+		if client, err := newClient(url, cfg); err == nil && client.Ping() == nil {
+			return client, nil
+		}
+	}
+	return app.NewReadOnly(paths)
+}
+
+// installedSlugs returns the slugs of the installed docsets
+func installedSlugs() []string {
+	application, err := completionBackend()
+	if err != nil {
+		return nil
+	}
+	defer application.Close()
+
+	docsets, err := application.ListInstalledDocsets()
+	if err != nil {
+		return nil
+	}
+	slugs := make([]string, 0, len(docsets))
+	for _, ds := range docsets {
+		slugs = append(slugs, ds.Slug)
+	}
+	return slugs
+}
+
+// availableSlugs returns the slugs in the cached manifest; completion
+// doesn't fetch it, so nothing completes before the first "search"
+func availableSlugs() []string {
+	backend, err := completionBackend()
+	if err != nil {
+		return nil
+	}
+	defer backend.Close()
+
+	// A server can't install docsets
+	application, ok := backend.(*app.App)
+	if !ok {
+		return nil
+	}
+	manifest, err := application.CachedDocsets()
+	if err != nil {
+		return nil
+	}
+	slugs := make([]string, 0, len(manifest))
+	for _, entry := range manifest {
+		slugs = append(slugs, entry.Slug)
+	}
+	slices.Sort(slugs)
+	return slugs
+}
+
+// symbolsOf returns symbols of the installed docset slug starting with
+// prefix
+func symbolsOf(slug, prefix string) []string {
+	application, err := completionBackend()
+	if err != nil {
+		return nil
+	}
+	defer application.Close()
+
+	symbols, err := application.CompleteSymbols(slug, prefix, maxSymbolCompletions)
+	if err != nil {
+		return nil
+	}
+	return symbols
+}
+
+// completeDocsets completes the first n arguments from the installed
+// docsets and the one after them from the symbols of the first
+func completeDocsets(n int) func(args []string, partial string) []string {
+	return func(args []string, partial string) []string {
+		switch {
+		case len(args) < n:
+			return installedSlugs()
+		case len(args) == n:
+			return symbolsOf(args[0], partial)
+		}
+		return nil
+	}
+}
+
+// completionScripts are printed by "lazydocs completion <shell>". Each asks
+// "lazydocs __complete" for candidates.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for lazydocs
+_lazydocs() {
+    local cur words cword
+    if declare -F _init_completion >/dev/null 2>&1; then
+        # Keep "ActiveRecord::Base" and "--format=json" as single words
+        _init_completion -n =: || return
+    else
+        words=("${COMP_WORDS[@]}")
+        cword=$COMP_CWORD
+        cur=${COMP_WORDS[COMP_CWORD]}
+    fi
+
+    local IFS=$'\n' c
+    local candidates=($(lazydocs __complete "${words[@]:1:cword-1}" "$cur" 2>/dev/null))
+    COMPREPLY=()
+    for c in "${candidates[@]}"; do
+        COMPREPLY+=("$(printf '%q' "$c")")
+    done
+
+    if declare -F __ltrim_colon_completions >/dev/null 2>&1; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+complete -o bashdefault -o default -F _lazydocs lazydocs
+`,
+
+	"zsh": `#compdef lazydocs
+# zsh completion for lazydocs
+_lazydocs() {
+    local -a candidates
+    candidates=("${(@f)$(lazydocs __complete "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    if (( ${#candidates} )); then
+        compadd -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+
+if [ "$funcstack[1]" = "_lazydocs" ]; then
+    _lazydocs "$@"
+else
+    compdef _lazydocs lazydocs
+fi
+`,
+
+	"fish": `# fish completion for lazydocs
+function __lazydocs_complete
+    set -l tokens (commandline -opc)
+    lazydocs __complete $tokens[2..-1] (commandline -ct) 2>/dev/null
+end
+
+complete -c lazydocs -f -a '(__lazydocs_complete)'
+complete -c lazydocs -l data-dir -r -F
+complete -c lazydocs -l config -r -F
+`,
+}
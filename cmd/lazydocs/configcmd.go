@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/config"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultEditor is used by "config edit" when $VISUAL and $EDITOR are unset
+const defaultEditor = "vi"
+
+// configActions are the first argument of the config command
+var configActions = []string{"get", "set", "unset", "edit", "path", "validate"}
+
+func configCommand() *command {
+	cmd := newCommand("config", "<get|set|unset|edit|path|validate> [key] [value]", "Show or change configuration", 1, 3)
+	cmd.help = `Actions:
+  get [key]          Print a setting, or every setting, including defaults
+  set <key> <value>  Change a setting; lists take YAML like "[go, ruby~3.3]"
+  unset <key>        Remove a setting so it takes its default
+  edit               Open the config file in $EDITOR, validating it on save
+  path               Print the config file path
+  validate           Report unknown keys and bad values with line numbers`
+	cmd.complete = func(args []string, partial string) []string {
+		switch {
+		case len(args) == 0:
+			return configActions
+		case len(args) == 1 && (args[0] == "get" || args[0] == "set" || args[0] == "unset"):
+			return config.Keys()
+		case len(args) == 2 && args[0] == "set" && args[1] == "theme":
+			return config.Themes()
+		}
+		return nil
+	}
+	cmd.run = func(args []string) error {
+		path := config.NewPaths(globals.dataDir, globals.configPath).ConfigPath
+
+		action, args := args[0], args[1:]
+		arity := map[string][2]int{
+			"get": {0, 1}, "set": {2, 2}, "unset": {1, 1},
+			"edit": {0, 0}, "path": {0, 0}, "validate": {0, 0},
+		}
+		bounds, ok := arity[action]
+		if !ok {
+			return usageError{fmt.Sprintf("unknown config action %q; use %s", action, strings.Join(configActions, ", "))}
+		}
+		if len(args) < bounds[0] || len(args) > bounds[1] {
+			return usageError{fmt.Sprintf("config %s: wrong number of arguments", action)}
+		}
+
+		switch action {
+		case "get":
+			return configGet(path, args)
+		case "set":
+			return updateConfig(path, func(data []byte) ([]byte, error) {
+				return config.Set(data, args[0], args[1])
+			})
+		case "unset":
+			return updateConfig(path, func(data []byte) ([]byte, error) {
+				return config.Unset(data, args[0])
+			})
+		case "edit":
+			return editConfig(path)
+		case "path":
+			fmt.Println(path)
+			return nil
+		default:
+			return validateConfig(path)
+		}
+	}
+	return cmd
+}
+
+func configGet(path string, args []string) error {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	if len(args) == 0 {
+		out, err := yaml.Marshal(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to format config: %w", err)
+		}
+		fmt.Print(string(out))
+		return nil
+	}
+
+	value, err := cfg.Get(args[0])
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// readConfigFile returns the config file's contents, or nothing when there
+// is no file yet
+func readConfigFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// updateConfig rewrites the config file with change applied
+func updateConfig(path string, change func([]byte) ([]byte, error)) error {
+	data, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	out, err := change(data)
+	if err != nil {
+		return err
+	}
+
+	return writeConfigFile(path, out)
+}
+
+func writeConfigFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// configProblems validates config file data, folding a YAML syntax error
+// into the list so callers report both the same way
+func configProblems(path string, data []byte) []string {
+	problems, err := config.Validate(data)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", path, err)}
+	}
+
+	lines := make([]string, len(problems))
+	for i, p := range problems {
+		lines[i] = fmt.Sprintf("%s:%d: %s: %s", path, p.Line, p.Key, p.Message)
+	}
+	return lines
+}
+
+func validateConfig(path string) error {
+	data, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		infof("No config file at %s; using defaults\n", path)
+		return nil
+	}
+
+	problems := configProblems(path, data)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s has %d problem(s)", path, len(problems))
+	}
+	infof("%s is valid\n", path)
+	return nil
+}
+
+// editConfig edits a copy of the config file and only replaces the file
+// once the copy validates
+func editConfig(path string) error {
+	data, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		if data, err = yaml.Marshal(config.DefaultConfig()); err != nil {
+			return fmt.Errorf("failed to format default config: %w", err)
+		}
+	}
+
+	tmp, err := os.CreateTemp("", "lazydocs-config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	for {
+		if err := runEditor(tmp.Name()); err != nil {
+			return err
+		}
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return fmt.Errorf("failed to read edited config: %w", err)
+		}
+
+		problems := configProblems(path, edited)
+		if len(problems) == 0 {
+			if err := writeConfigFile(path, edited); err != nil {
+				return err
+			}
+			infof("Saved %s\n", path)
+			return nil
+		}
+
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p)
+		}
+		if !confirm("Edit again?") {
+			return fmt.Errorf("config not saved; %s is unchanged", path)
+		}
+	}
+}
+
+// runEditor opens file in $VISUAL, $EDITOR or vi
+func runEditor(file string) error {
+	fields := strings.Fields(os.Getenv("VISUAL"))
+	if len(fields) == 0 {
+		fields = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(fields) == 0 {
+		fields = []string{defaultEditor}
+	}
+
+	cmd := exec.Command(fields[0], append(fields[1:], file)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run editor %s: %w", fields[0], err)
+	}
+	return nil
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to yes. It
+// answers no when stdin isn't a terminal.
+func confirm(question string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s [Y/n] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+
+	"github.com/lazydocs/lazydocs/internal/diff"
+	"golang.org/x/term"
+)
+
+func diffCommand() *command {
+	cmd := newCommand("diff", "<docset-a> <docset-b> <symbol|path>", "Show how an entry changed between two docsets", 3, 3)
+	cmd.complete = completeDocsets(2)
+	sideBySide := cmd.flags.Bool("side-by-side", false, "Show the versions in two columns")
+	context := cmd.flags.Int("context", 3, "Lines of context around each change")
+	cmd.run = func(args []string) error {
+		slugA, slugB, ref := args[0], args[1], args[2]
+
+		application, err := openBackend()
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		old, cur, err := application.CompareEntry(slugA, slugB, ref)
+		if err != nil {
+			return err
+		}
+
+		lines := diff.Lines(old.Content, cur.Content)
+		if !diff.Changed(lines) {
+			infof("%s is unchanged between %s and %s\n", old.Symbol, slugA, slugB)
+			return nil
+		}
+
+		opts := diff.Options{Color: term.IsTerminal(int(os.Stdout.Fd()))}
+		if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+			opts.Width = w
+		}
+
+		oldName := slugA + "/" + old.Path
+		newName := slugB + "/" + cur.Path
+		hunks := diff.Hunks(lines, *context)
+		if *sideBySide {
+			return diff.SideBySide(os.Stdout, oldName, newName, hunks, opts)
+		}
+		return diff.Unified(os.Stdout, oldName, newName, hunks, opts)
+	}
+	return cmd
+}
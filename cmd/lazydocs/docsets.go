@@ -0,0 +1,317 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+func installCommand() *command {
+	cmd := newCommand("install", "<docset>", "Install a docset (e.g., javascript, go, python~3.12)", 1, 1)
+	cmd.complete = func(args []string, partial string) []string {
+		if len(args) > 0 {
+			return nil
+		}
+		return availableSlugs()
+	}
+	cmd.run = func(args []string) error {
+		slug := args[0]
+
+		application, err := openLocalApp("install")
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		if globals.json {
+			err = application.InstallDocset(slug, progressEvents("install", slug))
+			emitResult("install", slug, err)
+			if err != nil {
+				return errReported
+			}
+			return nil
+		}
+
+		infof("Installing %s...\n", slug)
+
+		err = application.InstallDocset(slug, printProgress)
+		infof("\n")
+
+		if err != nil {
+			return err
+		}
+
+		infof("Successfully installed %s\n", slug)
+		return nil
+	}
+	return cmd
+}
+
+// printProgress shows download progress on a single updating line
+func printProgress(downloaded, total int64, status string) {
+	if total > 0 {
+		pct := float64(downloaded) / float64(total) * 100
+		infof("\r%s %.1f%% (%d/%d bytes)", status, pct, downloaded, total)
+	} else {
+		infof("\r%s %d bytes", status, downloaded)
+	}
+}
+
+func removeCommand() *command {
+	cmd := newCommand("remove", "<docset>", "Remove an installed docset", 1, 1)
+	cmd.aliases = []string{"delete"}
+	cmd.complete = func(args []string, partial string) []string {
+		if len(args) > 0 {
+			return nil
+		}
+		return installedSlugs()
+	}
+	cmd.run = func(args []string) error {
+		slug := args[0]
+
+		application, err := openLocalApp("remove")
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		err = application.RemoveDocset(slug)
+		if globals.json {
+			emitResult("remove", slug, err)
+			if err != nil {
+				return errReported
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		infof("Removed %s\n", slug)
+		return nil
+	}
+	return cmd
+}
+
+func listCommand() *command {
+	cmd := newCommand("list", "", "List installed docsets", 0, 0)
+	cmd.run = func(args []string) error {
+		application, err := openBackend()
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		docsets, err := application.ListInstalledDocsets()
+		if err != nil {
+			return err
+		}
+
+		if globals.json {
+			if docsets == nil {
+				docsets = []model.Docset{}
+			}
+			return writeJSON(docsets)
+		}
+
+		if len(docsets) == 0 {
+			infof("No docsets installed. Use 'lazydocs install <docset>' to install one.\n")
+			infof("\nPopular docsets: javascript, go, python~3.12, ruby~3.3, react, vue~3\n")
+			return nil
+		}
+
+		infof("Installed docsets:\n")
+		for _, ds := range docsets {
+			fmt.Printf("  %-20s %s (%d entries)\n", ds.Slug, ds.DisplayName, ds.EntryCount)
+		}
+		return nil
+	}
+	return cmd
+}
+
+func updateCommand() *command {
+	cmd := newCommand("update", "<docset|all>", "Update a docset or all docsets", 1, 1)
+	cmd.help = `With "all", a docset that fails to update is reported, the rest still update
+and the command fails once they are done.`
+	cmd.complete = func(args []string, partial string) []string {
+		if len(args) > 0 {
+			return nil
+		}
+		return append(installedSlugs(), "all")
+	}
+	cmd.run = func(args []string) error {
+		slug := args[0]
+
+		application, err := openLocalApp("update")
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		slugs := []string{slug}
+		if slug == "all" {
+			docsets, err := application.ListInstalledDocsets()
+			if err != nil {
+				return err
+			}
+
+			slugs = nil
+			for _, ds := range docsets {
+				slugs = append(slugs, ds.Slug)
+			}
+		}
+
+		var failed []string
+		for _, s := range slugs {
+			if globals.json {
+				err = application.InstallDocset(s, progressEvents("update", s))
+				emitResult("update", s, err)
+			} else {
+				infof("Updating %s...\n", s)
+				err = application.InstallDocset(s, nil)
+			}
+			if err == nil {
+				continue
+			}
+			// A single docset failing is an error; "all" reports it and goes on
+			if slug != "all" {
+				if globals.json {
+					return errReported
+				}
+				return err
+			}
+			if !globals.json {
+				fmt.Fprintf(os.Stderr, "Error updating %s: %v\n", s, err)
+			}
+			failed = append(failed, s)
+		}
+
+		if len(failed) > 0 {
+			if globals.json {
+				return errReported
+			}
+			return errors.New("failed to update " + strings.Join(failed, ", "))
+		}
+		infof("Update complete\n")
+		return nil
+	}
+	return cmd
+}
+
+func searchCommand() *command {
+	cmd := newCommand("search", "[filter]", "Search available docsets to install", 0, 1)
+	cmd.aliases = []string{"available"}
+	cmd.run = func(args []string) error {
+		filter := ""
+		if len(args) > 0 {
+			filter = strings.ToLower(args[0])
+		}
+
+		application, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		infof("Fetching available docsets...\n")
+
+		manifest, err := application.ListAvailableDocsets(false)
+		if err != nil {
+			return err
+		}
+
+		matches := model.Manifest{}
+		for _, entry := range manifest {
+			// Filter if specified
+			if filter != "" {
+				if !strings.Contains(strings.ToLower(entry.Slug), filter) &&
+					!strings.Contains(strings.ToLower(entry.Name), filter) {
+					continue
+				}
+			}
+			matches = append(matches, entry)
+		}
+
+		if globals.json {
+			return writeJSON(matches)
+		}
+
+		infof("\nAvailable docsets")
+		if filter != "" {
+			infof(" (filter: %q)", filter)
+		}
+		infof(":\n\n")
+
+		for _, entry := range matches {
+			sizeMB := float64(entry.DBSize) / 1024 / 1024
+			fmt.Printf("  %-25s %-30s (%.1f MB)\n", entry.Slug, entry.Name, sizeMB)
+		}
+
+		infof("\n%d docsets found\n", len(matches))
+		infof("\nInstall with: lazydocs install <slug>\n")
+		return nil
+	}
+	return cmd
+}
+
+// outdatedDocset pairs an installed docset with the newer release that the
+// manifest offers for it
+type outdatedDocset struct {
+	Installed model.Docset        `json:"installed"`
+	Available model.ManifestEntry `json:"available"`
+}
+
+func outdatedCommand() *command {
+	cmd := newCommand("outdated", "", "List installed docsets with a newer release", 0, 0)
+	cmd.run = func(args []string) error {
+		application, err := openLocalApp("outdated")
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		docsets, err := application.ListInstalledDocsets()
+		if err != nil {
+			return err
+		}
+		manifest, err := application.ListAvailableDocsets(false)
+		if err != nil {
+			return err
+		}
+
+		available := make(map[string]model.ManifestEntry, len(manifest))
+		for _, entry := range manifest {
+			available[entry.Slug] = entry
+		}
+
+		outdated := []outdatedDocset{}
+		for _, ds := range docsets {
+			if entry, ok := available[ds.Slug]; ok && entry.Mtime > ds.Mtime {
+				outdated = append(outdated, outdatedDocset{Installed: ds, Available: entry})
+			}
+		}
+
+		if globals.json {
+			return writeJSON(outdated)
+		}
+
+		if len(outdated) == 0 {
+			infof("All installed docsets are up to date\n")
+			return nil
+		}
+
+		infof("Outdated docsets:\n")
+		for _, o := range outdated {
+			fmt.Printf("  %-20s %s -> %s\n", o.Installed.Slug,
+				time.Unix(o.Installed.Mtime, 0).Format(time.DateOnly),
+				time.Unix(o.Available.Mtime, 0).Format(time.DateOnly))
+		}
+		infof("\nUpdate with: lazydocs update <slug|all>\n")
+		return nil
+	}
+	return cmd
+}
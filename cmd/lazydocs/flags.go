@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// globalOptions holds the flags every command accepts, before or after its
+// name
+type globalOptions struct {
+	dataDir    string // Overrides the data directory
+	configPath string // Overrides the config file
+	quiet      bool   // Suppresses progress and status messages
+	json       bool   // Prints JSON instead of tables
+	noProject  bool   // Ignores .lazydocs.yaml
+	remote     string // Server to use instead of local docsets
+}
+
+var globals globalOptions
+
+// localRemote as --remote uses local docsets even when the config names a
+// server
+const localRemote = "local"
+
+// globalFlagNames lists the flags registerGlobalFlags adds
+var globalFlagNames = map[string]bool{
+	"data-dir":   true,
+	"config":     true,
+	"quiet":      true,
+	"q":          true,
+	"json":       true,
+	"no-project": true,
+	"remote":     true,
+}
+
+// registerGlobalFlags adds the global flags to fs. Their defaults are the
+// current values, so flags given before the command survive being
+// registered again on the command's flag set.
+func registerGlobalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&globals.dataDir, "data-dir", globals.dataDir, "Data `directory` for docsets and the index")
+	fs.StringVar(&globals.configPath, "config", globals.configPath, "Config `file` to use")
+	fs.BoolVar(&globals.quiet, "quiet", globals.quiet, "Only print results and errors (-q)")
+	fs.BoolVar(&globals.quiet, "q", globals.quiet, "Only print results and errors")
+	fs.BoolVar(&globals.json, "json", globals.json, "Print JSON instead of tables (JSON lines of events for install, update and remove)")
+	fs.BoolVar(&globals.noProject, "no-project", globals.noProject, "Ignore .lazydocs.yaml and use every installed docset")
+	fs.StringVar(&globals.remote, "remote", globals.remote, "`URL` of a lazydocs server to read docs from, or \"local\"")
+}
+
+// isGlobalFlag reports whether name is one of the global flags
+func isGlobalFlag(name string) bool {
+	return globalFlagNames[name]
+}
+
+// infof prints a status message to stdout unless --quiet or --json is set
+func infof(format string, args ...any) {
+	if globals.quiet || globals.json {
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// notef prints a status message to stderr unless --quiet is set
+func notef(format string, args ...any) {
+	if globals.quiet {
+		return
+	}
+	fmt.Fprintf(os.Stderr, format, args...)
+}
+
+// parseArgs parses flags wherever they appear among args and returns the
+// remaining positional arguments in order. Everything after "--" is
+// positional, so query terms like "-word" can follow it.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+			// The flag package has printed the problem and the help
+			return nil, errUsage
+		}
+		rest := fs.Args()
+		consumed := len(args) - len(rest)
+		if consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
-	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/lazydocs/lazydocs/internal/app"
+	"github.com/lazydocs/lazydocs/internal/config"
+	"github.com/lazydocs/lazydocs/internal/remote"
 	"github.com/lazydocs/lazydocs/internal/tui"
 	"github.com/muesli/termenv"
 )
@@ -21,261 +24,218 @@ func init() {
 const version = "0.1.0"
 
 func main() {
-	if len(os.Args) < 2 {
-		runTUI("")
-		return
-	}
-
-	cmd := os.Args[1]
-
-	switch cmd {
-	case "install":
-		if len(os.Args) < 3 {
-			fmt.Fprintln(os.Stderr, "Usage: lazydocs install <docset>")
-			os.Exit(1)
-		}
-		installDocset(os.Args[2])
-
-	case "remove", "delete":
-		if len(os.Args) < 3 {
-			fmt.Fprintln(os.Stderr, "Usage: lazydocs remove <docset>")
-			os.Exit(1)
-		}
-		removeDocset(os.Args[2])
-
-	case "list":
-		listDocsets()
-
-	case "search", "available":
-		filter := ""
-		if len(os.Args) >= 3 {
-			filter = os.Args[2]
-		}
-		searchAvailable(filter)
-
-	case "update":
-		if len(os.Args) < 3 {
-			fmt.Fprintln(os.Stderr, "Usage: lazydocs update <docset|all>")
-			os.Exit(1)
-		}
-		updateDocset(os.Args[2])
+	os.Exit(exitCode(run(os.Args[1:])))
+}
 
-	case "--lookup", "-l":
-		if len(os.Args) < 3 {
-			fmt.Fprintln(os.Stderr, "Usage: lazydocs --lookup <symbol>")
-			os.Exit(1)
+// run parses the global flags in front of the command, then dispatches to
+// the command, or to the TUI when there is none
+func run(args []string) error {
+	root := flag.NewFlagSet("lazydocs", flag.ContinueOnError)
+	root.Usage = func() { printHelp(root.Output()) }
+	registerGlobalFlags(root)
+	showVersion := root.Bool("version", false, "Show version")
+	root.BoolVar(showVersion, "v", false, "Show version")
+	lookup := root.String("lookup", "", "Open the TUI and search for a symbol")
+	root.StringVar(lookup, "l", "", "Open the TUI and search for a symbol")
+	if err := root.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
 		}
-		runTUI(os.Args[2])
+		return errUsage
+	}
 
-	case "--version", "-v":
+	switch {
+	case *showVersion:
 		fmt.Printf("lazydocs %s\n", version)
+		return nil
+	case *lookup != "":
+		return runTUI(*lookup)
+	case root.NArg() == 0:
+		return runTUI("")
+	}
 
-	case "--help", "-h", "help":
-		printHelp()
-
-	default:
-		// Check if it looks like a lookup
-		if strings.HasPrefix(cmd, "-") {
-			fmt.Fprintf(os.Stderr, "Unknown option: %s\n", cmd)
-			printHelp()
-			os.Exit(1)
-		}
-		// Treat as lookup query
-		runTUI(cmd)
+	name := root.Arg(0)
+	if cmd := findCommand(name); cmd != nil {
+		return cmd.execute(root.Args()[1:])
 	}
-}
 
-func runTUI(lookup string) {
-	application, err := app.New()
+	// Anything else is a symbol to look up, unless it looks like a typo of
+	// a command and isn't in the index
+	application, err := openBackend()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing app: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to initialize app: %w", err)
 	}
 	defer application.Close()
 
-	p := tea.NewProgram(
-		tui.NewWithApp(application, lookup),
-		tea.WithAltScreen(),
-	)
-
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	if suggestCommand(name) != "" && !isIndexed(application, name) {
+		return unknownCommand(name)
 	}
+	return showTUI(application, name)
 }
 
-func installDocset(slug string) {
-	application, err := app.New()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-	defer application.Close()
-
-	fmt.Printf("Installing %s...\n", slug)
-
-	err = application.InstallDocset(slug, func(downloaded, total int64, status string) {
-		if total > 0 {
-			pct := float64(downloaded) / float64(total) * 100
-			fmt.Printf("\r%s %.1f%% (%d/%d bytes)", status, pct, downloaded, total)
-		} else {
-			fmt.Printf("\r%s %d bytes", status, downloaded)
-		}
-	})
-
-	fmt.Println()
-
-	if err != nil {
+// exitCode reports err, if it still needs reporting, and maps it to the
+// process exit code
+func exitCode(err error) int {
+	var usage usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errNoMatch):
+		return exitNoMatch
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, errReported):
+		return exitError
+	case errors.As(err, &usage):
+		fmt.Fprintf(os.Stderr, "Error: %v\nRun 'lazydocs help' for usage.\n", err)
+		return exitUsage
+	default:
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return exitError
 	}
-
-	fmt.Printf("Successfully installed %s\n", slug)
 }
 
-func removeDocset(slug string) {
-	application, err := app.New()
+// openApp initializes the app and rebuilds any docsets that a schema
+// migration flagged, so callers always see a current index
+func openApp() (*app.App, error) {
+	application, err := app.NewWithPaths(config.NewPaths(globals.dataDir, globals.configPath))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-	defer application.Close()
-
-	if err := application.RemoveDocset(slug); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return nil, err
 	}
 
-	fmt.Printf("Removed %s\n", slug)
-}
+	warnConfigProblems(application.Paths().ConfigPath)
 
-func listDocsets() {
-	application, err := app.New()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	if !globals.noProject {
+		p, err := findProject()
+		if err != nil {
+			application.Close()
+			return nil, err
+		}
+		if p != nil {
+			application.SetProject(p)
+		}
 	}
-	defer application.Close()
 
-	docsets, err := application.ListInstalledDocsets()
+	pending, err := application.PendingReindex()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		application.Close()
+		return nil, err
 	}
 
-	if len(docsets) == 0 {
-		fmt.Println("No docsets installed. Use 'lazydocs install <docset>' to install one.")
-		fmt.Println("\nPopular docsets: javascript, go, python~3.12, ruby~3.3, react, vue~3")
-		return
+	for _, ds := range pending {
+		notef("Rebuilding search index for %s...\n", ds.Slug)
+		if err := application.ReindexDocset(ds, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "Error rebuilding %s: %v\n", ds.Slug, err)
+		}
 	}
 
-	fmt.Println("Installed docsets:")
-	for _, ds := range docsets {
-		fmt.Printf("  %-20s %s (%d entries)\n", ds.Slug, ds.DisplayName, ds.EntryCount)
-	}
+	return application, nil
 }
 
-func updateDocset(slug string) {
-	application, err := app.New()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+// openBackend connects to the lazydocs server named by --remote or the
+// config, falling back to the local docsets when it doesn't answer
+func openBackend() (app.Backend, error) {
+	url := globals.remote
+	if url == "" || url == localRemote {
+		return openLocal(url)
 	}
-	defer application.Close()
+	return connect(url)
+}
 
-	if slug == "all" {
-		docsets, err := application.ListInstalledDocsets()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+// openLocal opens the local app unless the config names a server and
+// --remote local doesn't override it
+func openLocal(flag string) (app.Backend, error) {
+	if flag != localRemote {
+		path := config.NewPaths(globals.dataDir, globals.configPath).ConfigPath
+		if cfg, err := config.Load(path); err == nil && cfg.Remote.URL != "" {
+			return connect(cfg.Remote.URL)
 		}
+	}
+	return openApp()
+}
 
-		for _, ds := range docsets {
-			fmt.Printf("Updating %s...\n", ds.Slug)
-			if err := application.InstallDocset(ds.Slug, nil); err != nil {
-				fmt.Fprintf(os.Stderr, "Error updating %s: %v\n", ds.Slug, err)
-			}
-		}
-	} else {
-		fmt.Printf("Updating %s...\n", slug)
-		if err := application.InstallDocset(slug, nil); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+// openLocalApp opens the local app for a command that a lazydocs server
+// can't answer, refusing when --remote or remote.url names one
+func openLocalApp(name string) (*app.App, error) {
+	url := globals.remote
+	if url == "" {
+		path := config.NewPaths(globals.dataDir, globals.configPath).ConfigPath
+		if cfg, err := config.Load(path); err == nil {
+			url = cfg.Remote.URL
 		}
 	}
-
-	fmt.Println("Update complete")
+	if url != "" && url != localRemote {
+		return nil, usageError{fmt.Sprintf("%s only works with local docsets; pass --remote local to use them instead of %s", name, url)}
+	}
+	return openApp()
 }
 
-func searchAvailable(filter string) {
-	application, err := app.New()
+// connect returns a client for the server at url once it answers, and the
+// local app otherwise
+func connect(url string) (app.Backend, error) {
+	path := config.NewPaths(globals.dataDir, globals.configPath).ConfigPath
+	warnConfigProblems(path)
+	cfg, err := config.Load(path)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
-	defer application.Close()
 
-	fmt.Println("Fetching available docsets...")
-
-	manifest, err := application.ListAvailableDocsets(false)
+	client, err := newClient(url, cfg)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return nil, err
 	}
 
-	filter = strings.ToLower(filter)
-	count := 0
+	if err := client.Ping(); err != nil {
+		notef("Warning: %s: %v; using local docsets\n", client.URL(), err)
+		return openApp()
+	}
+	return client, nil
+}
 
-	fmt.Printf("\nAvailable docsets")
-	if filter != "" {
-		fmt.Printf(" (filter: %q)", filter)
+// newClient returns a client for the server at url, with the token from
+// the config or $LAZYDOCS_TOKEN
+func newClient(url string, cfg config.Config) (*remote.Client, error) {
+	token := cfg.Remote.Token
+	if token == "" {
+		token = os.Getenv("LAZYDOCS_TOKEN")
 	}
-	fmt.Println(":\n")
+	return remote.New(url, token, cfg)
+}
 
-	for _, entry := range manifest {
-		// Filter if specified
-		if filter != "" {
-			if !strings.Contains(strings.ToLower(entry.Slug), filter) &&
-				!strings.Contains(strings.ToLower(entry.Name), filter) {
-				continue
-			}
+// warnConfigProblems prints the config file's problems as warnings, as an
+// invalid theme or color would otherwise only show as odd rendering
+func warnConfigProblems(path string) {
+	if data, err := readConfigFile(path); err == nil && data != nil {
+		for _, p := range configProblems(path, data) {
+			notef("Warning: %s\n", p)
 		}
-
-		sizeMB := float64(entry.DBSize) / 1024 / 1024
-		fmt.Printf("  %-25s %-30s (%.1f MB)\n", entry.Slug, entry.Name, sizeMB)
-		count++
 	}
-
-	fmt.Printf("\n%d docsets found\n", count)
-	fmt.Println("\nInstall with: lazydocs install <slug>")
 }
 
-func printHelp() {
-	fmt.Print(`LazyDocs - Lazygit-style TUI for browsing DevDocs documentation
-
-Usage:
-  lazydocs                    Open the TUI
-  lazydocs <symbol>           Open TUI and search for symbol
-  lazydocs --lookup <symbol>  Same as above
+// isIndexed reports whether any installed docset mentions word
+func isIndexed(application app.Backend, word string) bool {
+	results, err := application.Search(word, "", "", 1, 0)
+	return err == nil && len(results) > 0
+}
 
-Commands:
-  install <docset>     Install a docset (e.g., javascript, go, python~3.12)
-  remove <docset>      Remove an installed docset
-  update <docset|all>  Update a docset or all docsets
-  list                 List installed docsets
-  search [filter]      Search available docsets to install
+func runTUI(lookup string) error {
+	application, err := openBackend()
+	if err != nil {
+		return fmt.Errorf("failed to initialize app: %w", err)
+	}
+	defer application.Close()
 
-Options:
-  --help, -h           Show this help
-  --version, -v        Show version
+	return showTUI(application, lookup)
+}
 
-Examples:
-  lazydocs install javascript
-  lazydocs install python~3.12
-  lazydocs search python        Search available Python docsets
-  lazydocs search               List all available docsets
-  lazydocs list
+// showTUI runs the TUI on an open backend, starting with a search for
+// lookup when it isn't empty
+func showTUI(application app.Backend, lookup string) error {
+	p := tea.NewProgram(
+		tui.NewWithApp(application, lookup),
+		tea.WithAltScreen(),
+	)
 
-Popular docsets: javascript, go, python~3.12, ruby~3.3, react, vue~3, rails~8.0
-`)
+	_, err := p.Run()
+	return err
 }
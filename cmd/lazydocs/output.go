@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/lazydocs/lazydocs/internal/data"
+)
+
+// writeJSON prints v as one indented JSON document on stdout
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// opEvent is one line of the JSON-lines stream written by install, update
+// and remove under --json. Each docset gets zero or more "progress" events
+// followed by exactly one "done" or "error" event.
+type opEvent struct {
+	Op         string `json:"op"`    // "install", "update" or "remove"
+	Event      string `json:"event"` // "progress", "done" or "error"
+	Slug       string `json:"slug"`
+	Status     string `json:"status,omitempty"`     // progress only
+	Downloaded int64  `json:"downloaded,omitempty"` // progress only, in bytes
+	Total      int64  `json:"total,omitempty"`      // progress only; 0 when unknown
+	Error      string `json:"error,omitempty"`      // error only
+}
+
+// emitEvent writes e as a single line
+func emitEvent(e opEvent) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	fmt.Println(string(line))
+}
+
+// emitResult writes the final event of an operation on slug
+func emitResult(op, slug string, err error) {
+	if err != nil {
+		emitEvent(opEvent{Op: op, Event: "error", Slug: slug, Error: err.Error()})
+		return
+	}
+	emitEvent(opEvent{Op: op, Event: "done", Slug: slug})
+}
+
+// progressEvents returns a progress callback that emits progress events,
+// at most one per status change or whole percent, so downloads don't flood
+// the stream
+func progressEvents(op, slug string) data.ProgressCallback {
+	lastStatus, lastPct := "", -1.0
+	return func(downloaded, total int64, status string) {
+		pct := -1.0
+		if total > 0 {
+			pct = math.Floor(float64(downloaded) / float64(total) * 100)
+		}
+		if status == lastStatus && pct == lastPct {
+			return
+		}
+		lastStatus, lastPct = status, pct
+
+		emitEvent(opEvent{
+			Op:         op,
+			Event:      "progress",
+			Slug:       slug,
+			Status:     status,
+			Downloaded: downloaded,
+			Total:      total,
+		})
+	}
+}
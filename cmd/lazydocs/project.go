@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/project"
+)
+
+// findProject looks for a project file from the working directory upward
+func findProject() (*project.File, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	p, err := project.Find(wd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project file: %w", err)
+	}
+	return p, nil
+}
+
+func syncCommand() *command {
+	cmd := newCommand("sync", "", "Install the docsets declared in .lazydocs.yaml", 0, 0)
+	cmd.help = `The project file is looked for in the working directory and its parents:
+
+  docsets:
+    - ruby~3.3
+    - rails~8.0
+
+Declared docsets that aren't installed are installed; nothing is installed
+if any slug is unknown. Other installed docsets are kept unless --prune is
+given.`
+	prune := cmd.flags.Bool("prune", false, "Also remove installed docsets the file doesn't declare")
+	cmd.run = func(args []string) error {
+		p, err := findProject()
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			return fmt.Errorf("no %s in this directory or its parents", project.FileName)
+		}
+
+		application, err := openLocalApp("sync")
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		installed, err := application.ListInstalledDocsets()
+		if err != nil {
+			return err
+		}
+		missing := p.Missing(installed)
+
+		// Check every slug first, so a typo doesn't leave a half-synced project
+		if len(missing) > 0 {
+			manifest, err := application.ListAvailableDocsets(false)
+			if err != nil {
+				return err
+			}
+			available := make(map[string]bool, len(manifest))
+			for _, entry := range manifest {
+				available[entry.Slug] = true
+			}
+			var unknown []string
+			for _, slug := range missing {
+				if !available[slug] {
+					unknown = append(unknown, slug)
+				}
+			}
+			if len(unknown) > 0 {
+				return fmt.Errorf("%s: unknown docsets %s; see 'lazydocs search'", p.Path, strings.Join(unknown, ", "))
+			}
+		}
+
+		var failed []string
+		for _, slug := range missing {
+			if globals.json {
+				err = application.InstallDocset(slug, progressEvents("install", slug))
+				emitResult("install", slug, err)
+			} else {
+				infof("Installing %s...\n", slug)
+				err = application.InstallDocset(slug, printProgress)
+				infof("\n")
+			}
+			if err != nil {
+				if !globals.json {
+					fmt.Fprintf(os.Stderr, "Error installing %s: %v\n", slug, err)
+				}
+				failed = append(failed, slug)
+			}
+		}
+
+		removed := 0
+		if *prune {
+			for _, ds := range p.Extra(installed) {
+				err = application.RemoveDocset(ds.Slug)
+				if globals.json {
+					emitResult("remove", ds.Slug, err)
+				}
+				if err != nil {
+					if !globals.json {
+						fmt.Fprintf(os.Stderr, "Error removing %s: %v\n", ds.Slug, err)
+					}
+					failed = append(failed, ds.Slug)
+					continue
+				}
+				infof("Removed %s\n", ds.Slug)
+				removed++
+			}
+		}
+
+		if len(failed) > 0 {
+			if globals.json {
+				return errReported
+			}
+			return errors.New("failed to sync " + strings.Join(failed, ", "))
+		}
+		infof("%s is in sync: %d installed, %d removed\n", p.Path, len(missing), removed)
+		return nil
+	}
+	return cmd
+}
+
+func detectCommand() *command {
+	cmd := newCommand("detect", "[dir]", "Suggest docsets from a project's dependency files", 0, 1)
+	cmd.help = `Reads go.mod, package.json, Gemfile.lock, pyproject.toml and Cargo.toml in dir,
+by default the working directory, or the nearest directory above it that
+has any of them, and picks the docset versions matching the versions the
+project uses.`
+	cmd.run = func(args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+
+		application, err := openLocalApp("detect")
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		suggestions, err := application.DetectDocsets(dir)
+		if err != nil {
+			return err
+		}
+
+		if globals.json {
+			if suggestions == nil {
+				suggestions = []project.Suggestion{}
+			}
+			return writeJSON(suggestions)
+		}
+
+		if len(suggestions) == 0 {
+			infof("No docsets detected in %s\n", dir)
+			return nil
+		}
+
+		infof("Docsets for %s:\n", dir)
+		for _, s := range suggestions {
+			status := ""
+			if s.Installed {
+				status = " (installed)"
+			}
+			fmt.Printf("  %-20s %s: %s%s\n", s.Slug, s.Source, s.Reason, status)
+		}
+		infof("\nInstall with: lazydocs install <slug>, or list them in %s and run 'lazydocs sync'\n", project.FileName)
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/app"
+	"github.com/lazydocs/lazydocs/internal/model"
+	"golang.org/x/term"
+)
+
+// queryResult is one line of query output
+type queryResult struct {
+	Symbol   string  `json:"symbol"`
+	Docset   string  `json:"docset"`
+	Version  string  `json:"version"`
+	Type     string  `json:"type"`
+	Path     string  `json:"path"`
+	Rank     float64 `json:"rank"`
+	Snippet  string  `json:"snippet,omitempty"`  // Plain text, without highlighting
+	Language string  `json:"language,omitempty"` // Code results only
+	Code     string  `json:"code,omitempty"`     // Code results only
+}
+
+// queryOutput is the document written by --format json
+type queryOutput struct {
+	Query      string        `json:"query"`
+	Results    []queryResult `json:"results"`
+	Suggestion string        `json:"suggestion,omitempty"`
+}
+
+func queryCommand() *command {
+	cmd := newCommand("query", "<terms>", "Search installed docs and print the results", 1, -1)
+	cmd.help = `Terms use the TUI's search syntax. Put terms starting with "-" after "--".
+Exits 3 when nothing matches.`
+	cmd.flagValues = map[string][]string{"format": {"text", "json", "tsv"}}
+	docset := cmd.flags.String("docset", "", "Only search this docset (the version is optional)")
+	limit := cmd.flags.Int("limit", 20, "Maximum number of results")
+	format := cmd.flags.String("format", "text", "Output format: text, json or tsv")
+	code := cmd.flags.Bool("code", false, "Search code examples instead of entries")
+	cmd.run = func(args []string) error {
+		if globals.json {
+			*format = "json"
+		}
+		switch *format {
+		case "text", "json", "tsv":
+		default:
+			return usageError{fmt.Sprintf("unknown format %q", *format)}
+		}
+		if *limit < 1 || *limit > app.MaxLimit {
+			return usageError{fmt.Sprintf("--limit must be from 1 to %d", app.MaxLimit)}
+		}
+		query := strings.Join(args, " ")
+
+		application, err := openBackend()
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		name, version := "", ""
+		if *docset != "" {
+			name, version = model.ParseSlug(*docset)
+		}
+
+		var results []queryResult
+		var total int
+		if *code {
+			examples, err := application.SearchExamples(query, name, version, *limit, 0)
+			if err != nil {
+				return err
+			}
+			if total, err = application.CountExamples(query, name, version); err != nil {
+				return err
+			}
+			for _, ex := range examples {
+				results = append(results, queryResult{
+					Symbol:   ex.Entry.Symbol,
+					Docset:   ex.Entry.Docset,
+					Version:  ex.Entry.Version,
+					Type:     ex.Entry.Type,
+					Path:     ex.Entry.Path,
+					Rank:     ex.Rank,
+					Language: ex.Language,
+					Code:     ex.Code,
+				})
+			}
+		} else {
+			found, err := application.Search(query, name, version, *limit, 0)
+			if err != nil {
+				return err
+			}
+			if total, err = application.CountResults(query, name, version); err != nil {
+				return err
+			}
+			for _, r := range found {
+				results = append(results, queryResult{
+					Symbol:  r.Symbol,
+					Docset:  r.Docset,
+					Version: r.Version,
+					Type:    r.Type,
+					Path:    r.Path,
+					Rank:    r.Rank,
+					Snippet: r.Snippet,
+				})
+			}
+		}
+
+		// Same threshold as the TUI: offer a correction when there are few
+		// matches in all, however few the limit let through
+		suggestion := ""
+		if total < app.SuggestBelow {
+			suggestion, _ = application.Suggest(query, name, version)
+		}
+
+		switch *format {
+		case "text":
+			writeQueryText(os.Stdout, results, term.IsTerminal(int(os.Stdout.Fd())))
+		case "tsv":
+			writeQueryTSV(os.Stdout, results)
+		case "json":
+			for i := range results {
+				results[i].Snippet = plainSnippet(results[i].Snippet)
+			}
+			out := queryOutput{Query: query, Results: results, Suggestion: suggestion}
+			if out.Results == nil {
+				out.Results = []queryResult{}
+			}
+			if err := writeJSON(out); err != nil {
+				return err
+			}
+		}
+
+		// JSON carries the suggestion itself; keep it off stdout otherwise
+		if suggestion != "" && *format != "json" {
+			notef("Did you mean: %s?\n", suggestion)
+		}
+		if len(results) == 0 {
+			if *format == "text" {
+				notef("No results for %q\n", query)
+			}
+			return errNoMatch
+		}
+		return nil
+	}
+	return cmd
+}
+
+func writeQueryText(w io.Writer, results []queryResult, color bool) {
+	for i, r := range results {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		slug := r.Docset
+		if r.Version != "" {
+			slug += "~" + r.Version
+		}
+		symbol := r.Symbol
+		if color {
+			symbol = "\x1b[1m" + symbol + "\x1b[22m"
+		}
+		fmt.Fprintf(w, "%s  [%s]  %s  (%.2f)\n", symbol, slug, r.Path, r.Rank)
+
+		if r.Code != "" {
+			if r.Language != "" {
+				fmt.Fprintf(w, "    [%s]\n", r.Language)
+			}
+			for _, line := range strings.Split(strings.TrimRight(r.Code, "\n"), "\n") {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+			continue
+		}
+		if snippet := flattenSnippet(r.Snippet, color); snippet != "" {
+			fmt.Fprintf(w, "    %s\n", snippet)
+		}
+	}
+}
+
+// writeQueryTSV writes one result per line: symbol, docset, version, path,
+// rank, then the snippet, or the language and code for code results
+func writeQueryTSV(w io.Writer, results []queryResult) {
+	for _, r := range results {
+		fields := []string{r.Symbol, r.Docset, r.Version, r.Path, fmt.Sprintf("%.4f", r.Rank)}
+		if r.Code != "" {
+			code := strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`).Replace(r.Code)
+			fields = append(fields, r.Language, code)
+		} else {
+			fields = append(fields, flattenSnippet(r.Snippet, false))
+		}
+		for i, f := range fields {
+			fields[i] = strings.ReplaceAll(f, "\t", " ")
+		}
+		fmt.Fprintln(w, strings.Join(fields, "\t"))
+	}
+}
+
+// flattenSnippet collapses a snippet onto one line, rendering its match
+// markers as reverse video when color is on and dropping them otherwise
+func flattenSnippet(snippet string, color bool) string {
+	snippet = strings.Join(strings.Fields(snippet), " ")
+	if color {
+		return strings.NewReplacer("<mark>", "\x1b[7m", "</mark>", "\x1b[27m").Replace(snippet)
+	}
+	return plainSnippet(snippet)
+}
+
+// plainSnippet removes the match markers from a snippet
+func plainSnippet(snippet string) string {
+	return strings.NewReplacer("<mark>", "", "</mark>", "").Replace(snippet)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+
+	"github.com/lazydocs/lazydocs/internal/rpc"
+)
+
+func rpcCommand() *command {
+	cmd := newCommand("rpc", "", "Answer JSON-RPC 2.0 requests on stdin for editor plugins", 0, 0)
+	cmd.help = `Methods:
+  search       {query, docset?, limit?, offset?}   Search, with the TUI's query syntax
+  getEntry     {docset, path, format?, width?}     An entry as "markdown" or "ansi"
+  listDocsets  {available?, refresh?}              Installed or installable docsets
+  install      {docset}                            Install, sending "progress" notifications
+  resolveLink  {docset, from, href}                The entry a link in an entry leads to
+
+Send one JSON message per line, or frame messages with Content-Length
+headers as LSP does. The process runs until stdin closes.`
+	cmd.run = func(args []string) error {
+		application, err := openBackend()
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		return rpc.New(application).Serve(os.Stdin, os.Stdout)
+	}
+	return cmd
+}
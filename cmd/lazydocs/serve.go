@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/lazydocs/lazydocs/internal/server"
+)
+
+// defaultAddr only accepts connections from this machine
+const defaultAddr = "127.0.0.1:7280"
+
+func serveCommand() *command {
+	cmd := newCommand("serve", "", "Serve the index over HTTP as a JSON API and web UI", 0, 0)
+	cmd.help = `API (GET, JSON):
+  /api/docsets                            Installed docsets
+  /api/search?q=&docset=&limit=&offset=   Search, with the TUI's query syntax
+  /api/docsets/<slug>/entries?limit=&after=
+                                          Entries of a docset, a page at a time
+  /api/docsets/<slug>/entries/<path>      One entry with its Markdown content
+  /api/examples, /api/suggest, /api/fuzzy, /api/related, /api/headings,
+  /api/symbols, /api/resolve, /api/compare
+                                          What "lazydocs --remote" needs
+
+The web UI is at /. With a token, API clients send "Authorization: Bearer
+<token>" and browsers open any page once with ?token=<token>.`
+	addr := cmd.flags.String("addr", defaultAddr, "`Address` to listen on; use 0.0.0.0:7280 to share on the network")
+	token := cmd.flags.String("token", "", "Bearer `token` clients must send (default $LAZYDOCS_TOKEN)")
+	cmd.run = func(args []string) error {
+		// A server shares every docset, whatever project it was started in
+		globals.noProject = true
+		if *token == "" {
+			*token = os.Getenv("LAZYDOCS_TOKEN")
+		}
+		application, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		listener, err := net.Listen("tcp", *addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", *addr, err)
+		}
+		if *token == "" && !isLoopback(listener.Addr()) {
+			notef("Warning: serving on %s without --token; anyone who can reach it can read the index\n", listener.Addr())
+		}
+
+		srv := &http.Server{
+			Handler:           server.New(application, *token),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdown)
+		}()
+
+		infof("Serving on http://%s (Ctrl+C to stop)\n", listener.Addr())
+		if err := srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+	return cmd
+}
+
+// isLoopback reports whether a listener only accepts local connections
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
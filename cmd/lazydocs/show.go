@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/app"
+	"github.com/lazydocs/lazydocs/internal/model"
+	"github.com/lazydocs/lazydocs/internal/remote"
+	"github.com/lazydocs/lazydocs/internal/render"
+	"golang.org/x/term"
+)
+
+// defaultPager is used by --pager when $PAGER is unset; -R passes the
+// rendered colors through
+const defaultPager = "less -R"
+
+func showCommand() *command {
+	cmd := newCommand("show", "<docset[~version]> <symbol|path>", "Print an entry, rendered on a terminal", 2, 2)
+	cmd.help = `Renders the entry when stdout is a terminal and prints raw Markdown otherwise.
+A ref that is neither a path nor a symbol shows the best search result. When
+a symbol names several entries, their paths are listed and show exits 3.`
+	cmd.complete = completeDocsets(1)
+	pager := cmd.flags.Bool("pager", false, "Page the output through $PAGER")
+	cmd.run = func(args []string) error {
+		slug, ref := args[0], args[1]
+
+		application, err := openBackend()
+		if err != nil {
+			return err
+		}
+		defer application.Close()
+
+		matches, err := application.ResolveEntry(slug, ref)
+		if isNoEntry(err) {
+			// Not a path or symbol: show the best search result instead
+			name, version := model.ParseSlug(slug)
+			results, searchErr := application.Search(ref, name, version, 1, 0)
+			if searchErr != nil || len(results) == 0 {
+				notef("No entry %q in %s\n", ref, slug)
+				return errNoMatch
+			}
+			notef("No entry %q in %s; showing %s\n", ref, slug, results[0].Entry.Path)
+			matches, err = []model.Entry{results[0].Entry}, nil
+		}
+		if err != nil {
+			return err
+		}
+
+		match, ok := bestMatch(matches, ref)
+		if !ok {
+			notef("%q matches %d entries in %s; pass one of these paths:\n\n", ref, len(matches), slug)
+			for _, e := range matches {
+				fmt.Printf("  %-40s %-20s %s\n", e.Path, e.Type, e.Symbol)
+			}
+			return errNoMatch
+		}
+
+		entry, err := application.GetEntry(match.Docset, match.Version, match.Path)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", match.Path, err)
+		}
+
+		// Piped: leave the Markdown for the next program in the pipeline
+		if !term.IsTerminal(int(os.Stdout.Fd())) {
+			_, err = io.WriteString(os.Stdout, entry.Content)
+			return err
+		}
+
+		width := 80
+		if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w < width {
+			width = w
+		}
+		content, _ := render.Markdown(entry.Content, application.Config().Theme, width)
+
+		if *pager {
+			return runPager(content)
+		}
+		_, err = io.WriteString(os.Stdout, content)
+		return err
+	}
+	return cmd
+}
+
+// bestMatch picks the entry ref refers to: an exact path, or the only entry
+// whose symbol matches. Lookup results are already ordered exact path, then
+// exact-case symbol, first.
+func bestMatch(matches []model.Entry, ref string) (model.Entry, bool) {
+	if len(matches) == 1 || matches[0].Path == ref {
+		return matches[0], true
+	}
+	if matches[0].Symbol == ref && matches[1].Symbol != ref {
+		return matches[0], true
+	}
+	return model.Entry{}, false
+}
+
+// isNoEntry reports whether err means the docset, local or on a lazydocs
+// server, has no entry for a ref
+func isNoEntry(err error) bool {
+	var remoteErr *remote.Error
+	return errors.Is(err, app.ErrNoEntry) ||
+		(errors.As(err, &remoteErr) && remoteErr.Status == http.StatusNotFound)
+}
+
+// runPager writes content through $PAGER, or less when it is unset
+func runPager(content string) error {
+	fields := strings.Fields(os.Getenv("PAGER"))
+	if len(fields) == 0 {
+		fields = strings.Fields(defaultPager)
+	}
+
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run pager %s: %w", fields[0], err)
+	}
+	return nil
+}
@@ -1,12 +1,16 @@
 package app
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/lazydocs/lazydocs/internal/config"
 	"github.com/lazydocs/lazydocs/internal/data"
 	"github.com/lazydocs/lazydocs/internal/db"
 	"github.com/lazydocs/lazydocs/internal/model"
+	"github.com/lazydocs/lazydocs/internal/project"
 )
 
 // App is the main application orchestrator
@@ -19,12 +23,18 @@ type App struct {
 	storage  *data.Storage
 	indexer  *db.Indexer
 	searcher *db.Searcher
+	symbols  symbolCache
+	project  *project.File
 }
 
-// New creates a new App instance
+// New creates a new App instance using the default paths
 func New() (*App, error) {
-	paths := config.DefaultPaths()
+	return NewWithPaths(config.DefaultPaths())
+}
 
+// NewWithPaths creates a new App instance that keeps its data and config
+// at the given paths
+func NewWithPaths(paths config.Paths) (*App, error) {
 	// Ensure directories exist
 	if err := paths.EnsureDirs(); err != nil {
 		return nil, fmt.Errorf("failed to create directories: %w", err)
@@ -42,12 +52,33 @@ func New() (*App, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Create components
+	return newApp(paths, cfg, database), nil
+}
+
+// NewReadOnly creates an App that reads the index at paths as it is,
+// without creating or migrating it. Installing and removing docsets fail.
+func NewReadOnly(paths config.Paths) (*App, error) {
+	cfg, err := config.Load(paths.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	database, err := db.OpenReadOnly(paths.DBPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	return newApp(paths, cfg, database), nil
+}
+
+// newApp creates the components of an App around an open database
+func newApp(paths config.Paths, cfg config.Config, database *db.DB) *App {
 	client := data.NewClient()
 	manifest := data.NewManifestCache(paths.ManifestPath, client)
 	storage := data.NewStorage(paths.DocsDir)
 	indexer := db.NewIndexer(database)
 	searcher := db.NewSearcher(database)
+	searcher.SetRankWeights(db.RankWeightsFromConfig(cfg.Search))
 
 	return &App{
 		paths:    paths,
@@ -58,7 +89,7 @@ func New() (*App, error) {
 		storage:  storage,
 		indexer:  indexer,
 		searcher: searcher,
-	}, nil
+	}
 }
 
 // Close cleans up app resources
@@ -74,11 +105,111 @@ func (a *App) ListInstalledDocsets() ([]model.Docset, error) {
 	return a.searcher.ListDocsets()
 }
 
+// SetProject scopes the app to the docsets a project file declares:
+// ScopedDocsets lists only those, and searches that don't name a docset
+// cover only those
+func (a *App) SetProject(p *project.File) {
+	a.project = p
+
+	var scope []model.Docset
+	for _, slug := range p.Docsets {
+		name, version := model.ParseSlug(slug)
+		scope = append(scope, model.Docset{Name: name, Version: version, Slug: slug})
+	}
+	a.searcher.SetScope(scope)
+}
+
+// Project returns the project the app is scoped to, or nil
+func (a *App) Project() *project.File {
+	return a.project
+}
+
+// ScopedDocsets returns the installed docsets of the project, in the order
+// its file lists them, or every installed docset outside a project
+func (a *App) ScopedDocsets() ([]model.Docset, error) {
+	docsets, err := a.searcher.ListDocsets()
+	if err != nil || a.project == nil {
+		return docsets, err
+	}
+	return a.project.Scope(docsets), nil
+}
+
+// DetectDocsets suggests docsets for the project in dir from its
+// dependency files (go.mod, package.json, Gemfile.lock, pyproject.toml,
+// Cargo.toml), marking the ones already installed
+func (a *App) DetectDocsets(dir string) ([]project.Suggestion, error) {
+	root, err := project.FindRoot(dir)
+	if err != nil || root == "" {
+		return nil, err
+	}
+	reqs, err := project.Requirements(root)
+	if err != nil || len(reqs) == 0 {
+		return nil, err
+	}
+
+	manifest, err := a.manifest.Get(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
+	}
+	suggestions := project.Match(reqs, manifest)
+
+	installed, err := a.searcher.ListDocsets()
+	if err != nil {
+		return nil, err
+	}
+	for i := range suggestions {
+		suggestions[i].Installed = slices.ContainsFunc(installed, func(ds model.Docset) bool {
+			return ds.Slug == suggestions[i].Slug
+		})
+	}
+	return suggestions, nil
+}
+
+// OfferDocsets returns the detected docsets of the project holding dir
+// that aren't installed, until MarkOffered records the project, so the
+// offer isn't repeated on every launch
+func (a *App) OfferDocsets(dir string) ([]project.Suggestion, error) {
+	root, err := project.FindRoot(dir)
+	if err != nil || root == "" {
+		return nil, err
+	}
+	offered, err := project.LoadOffered(a.paths.OfferedPath)
+	if err != nil || offered.Has(root) {
+		return nil, err
+	}
+
+	suggestions, err := a.DetectDocsets(root)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(suggestions, func(s project.Suggestion) bool { return s.Installed }), nil
+}
+
+// MarkOffered records that the project holding dir was offered its
+// detected docsets
+func (a *App) MarkOffered(dir string) error {
+	root, err := project.FindRoot(dir)
+	if err != nil || root == "" {
+		return err
+	}
+	offered, err := project.LoadOffered(a.paths.OfferedPath)
+	if err != nil || offered.Has(root) {
+		return err
+	}
+	return offered.Add(root)
+}
+
 // ListAvailableDocsets returns all available docsets from DevDocs
 func (a *App) ListAvailableDocsets(forceRefresh bool) (model.Manifest, error) {
 	return a.manifest.Get(forceRefresh)
 }
 
+// CachedDocsets returns the manifest cached on disk without fetching it,
+// for callers that must not wait on the network
+func (a *App) CachedDocsets() (model.Manifest, error) {
+	return a.manifest.Cached()
+}
+
 // InstallDocset downloads and indexes a docset
 func (a *App) InstallDocset(slug string, progress data.ProgressCallback) error {
 	// Get manifest entry
@@ -100,12 +231,17 @@ func (a *App) InstallDocset(slug string, progress data.ProgressCallback) error {
 	}
 
 	// Download and index
+	// Drop cached symbols so fuzzy search sees the new version
+	defer a.symbols.invalidate(slug)
+
 	downloader := data.NewDownloader(a.client, a.indexer, a.storage)
 	return downloader.Download(*entry, progress)
 }
 
 // RemoveDocset removes a docset
 func (a *App) RemoveDocset(slug string) error {
+	a.symbols.invalidate(slug)
+
 	// Remove from database
 	if err := a.indexer.RemoveDocset(slug); err != nil {
 		return fmt.Errorf("failed to remove from database: %w", err)
@@ -119,14 +255,71 @@ func (a *App) RemoveDocset(slug string) error {
 	return nil
 }
 
-// Search performs a full-text search
-func (a *App) Search(query, docset, version string, limit int) ([]model.SearchResult, error) {
-	return a.searcher.Search(query, docset, version, limit)
+// PendingReindex returns docsets that must be rebuilt after a schema migration
+func (a *App) PendingReindex() ([]model.Docset, error) {
+	return a.indexer.PendingReindex()
 }
 
-// ListEntries returns entries for a docset (for browsing)
-func (a *App) ListEntries(docset, version string, limit int) ([]model.Entry, error) {
-	return a.searcher.ListEntries(docset, version, limit)
+// ReindexDocset rebuilds an installed docset's index from its raw files
+func (a *App) ReindexDocset(docset model.Docset, progress data.ProgressCallback) error {
+	defer a.symbols.invalidate(docset.Slug)
+
+	downloader := data.NewDownloader(a.client, a.indexer, a.storage)
+	return downloader.Reindex(docset, progress)
+}
+
+// Search performs a full-text search, returning up to limit results
+// starting at offset
+func (a *App) Search(query, docset, version string, limit, offset int) ([]model.SearchResult, error) {
+	return a.searcher.Search(query, docset, version, limit, offset)
+}
+
+// CountResults returns the total number of matches for a search
+func (a *App) CountResults(query, docset, version string) (int, error) {
+	return a.searcher.CountResults(query, docset, version)
+}
+
+// SearchExamples searches code examples only, returning up to limit
+// blocks starting at offset
+func (a *App) SearchExamples(query, docset, version string, limit, offset int) ([]model.Example, error) {
+	return a.searcher.SearchExamples(query, docset, version, limit, offset)
+}
+
+// CountExamples returns the total number of code examples matching a search
+func (a *App) CountExamples(query, docset, version string) (int, error) {
+	return a.searcher.CountExamples(query, docset, version)
+}
+
+// SuggestBelow is the match count under which the TUI and CLI offer a
+// respelling of the query
+const SuggestBelow = 3
+
+// Suggest returns a respelling of a query using the words of the docsets
+// it searches, or "" if there is nothing to suggest
+func (a *App) Suggest(query, docset, version string) (string, error) {
+	return a.searcher.Suggest(query, docset, version)
+}
+
+// Related returns entries similar to the given one, from its own docset
+// unless allDocsets is set
+func (a *App) Related(entry model.Entry, allDocsets bool, limit int) ([]model.SearchResult, error) {
+	return a.searcher.Related(entry, allDocsets, limit)
+}
+
+// Headings returns the outline of an entry
+func (a *App) Headings(entryID int64) ([]model.Heading, error) {
+	return a.searcher.Headings(entryID)
+}
+
+// ListEntries returns entries for a docset (for browsing), up to limit
+// entries after the given one; pass nil for the first page
+func (a *App) ListEntries(docset, version string, after *model.Entry, limit int) ([]model.Entry, error) {
+	return a.searcher.ListEntries(docset, version, after, limit)
+}
+
+// CountEntries returns the number of entries in a docset
+func (a *App) CountEntries(docset, version string) (int, error) {
+	return a.searcher.CountEntries(docset, version)
 }
 
 // GetEntry returns a specific entry
@@ -134,6 +327,108 @@ func (a *App) GetEntry(docset, version, path string) (*model.Entry, error) {
 	return a.searcher.GetEntry(docset, version, path)
 }
 
+// CompleteSymbols returns up to limit symbols of an installed docset that
+// start with prefix
+func (a *App) CompleteSymbols(slug, prefix string, limit int) ([]string, error) {
+	name, version := model.ParseSlug(slug)
+	return a.searcher.CompleteSymbols(name, version, prefix, limit)
+}
+
+// ErrNoEntry is returned by ResolveEntry when no entry matches ref
+var ErrNoEntry = errors.New("no entry")
+
+// ResolveEntry finds the entries of an installed docset matching ref, a
+// path or symbol, best match first. Content is not loaded.
+func (a *App) ResolveEntry(slug, ref string) ([]model.Entry, error) {
+	name, version := model.ParseSlug(slug)
+	entries, err := a.searcher.FindEntries(name, version, ref)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w %q in %s", ErrNoEntry, ref, slug)
+	}
+	return entries, nil
+}
+
+// CompareEntry loads the entry for ref from two installed docsets, usually
+// two versions of one. The second is matched by the first's path, falling
+// back to its symbol, so renamed pages still line up.
+func (a *App) CompareEntry(slugA, slugB, ref string) (*model.Entry, *model.Entry, error) {
+	matches, err := a.ResolveEntry(slugA, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	old, err := a.searcher.GetEntry(matches[0].Docset, matches[0].Version, matches[0].Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load %s from %s: %w", ref, slugA, err)
+	}
+
+	name, version := model.ParseSlug(slugB)
+	cur, err := a.searcher.GetEntry(name, version, old.Path)
+	if errors.Is(err, sql.ErrNoRows) {
+		matches, err = a.ResolveEntry(slugB, old.Symbol)
+		if err != nil {
+			return nil, nil, err
+		}
+		cur, err = a.searcher.GetEntry(name, version, matches[0].Path)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load %s from %s: %w", ref, slugB, err)
+	}
+
+	return old, cur, nil
+}
+
+// Changes lists the symbols added, removed and changed going from one
+// installed docset to another
+func (a *App) Changes(slugA, slugB string) (*model.Changelog, error) {
+	for _, slug := range []string{slugA, slugB} {
+		if err := a.requireInstalled(slug); err != nil {
+			return nil, err
+		}
+	}
+
+	nameA, versionA := model.ParseSlug(slugA)
+	nameB, versionB := model.ParseSlug(slugB)
+	changes, err := a.searcher.CompareVersions(nameA, versionA, nameB, versionB)
+	if err != nil {
+		return nil, err
+	}
+	changes.From, changes.To = slugA, slugB
+	return changes, nil
+}
+
+// ChangesSinceUpdate lists the symbols added, removed and changed by the
+// latest install of a docset
+func (a *App) ChangesSinceUpdate(slug string) (*model.Changelog, error) {
+	if err := a.requireInstalled(slug); err != nil {
+		return nil, err
+	}
+
+	name, version := model.ParseSlug(slug)
+	changes, err := a.searcher.ChangesSinceUpdate(name, version)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", slug, err)
+	}
+	changes.From, changes.To = slug+" (previous install)", slug
+	return changes, nil
+}
+
+// requireInstalled returns an error unless the docset is installed
+func (a *App) requireInstalled(slug string) error {
+	docsets, err := a.searcher.ListDocsets()
+	if err != nil {
+		return err
+	}
+	for _, ds := range docsets {
+		if ds.Slug == slug {
+			return nil
+		}
+	}
+	return fmt.Errorf("docset %s is not installed", slug)
+}
+
 // Paths returns the app paths
 func (a *App) Paths() config.Paths {
 	return a.paths
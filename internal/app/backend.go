@@ -0,0 +1,52 @@
+package app
+
+import (
+	"github.com/lazydocs/lazydocs/internal/config"
+	"github.com/lazydocs/lazydocs/internal/data"
+	"github.com/lazydocs/lazydocs/internal/model"
+	"github.com/lazydocs/lazydocs/internal/project"
+)
+
+// MaxLimit is the most results or entries a Backend is asked for in one
+// page. "lazydocs serve" refuses larger pages, so the CLI checks its own
+// limits against it whichever backend it uses.
+const MaxLimit = 500
+
+// Backend is the index the TUI and CLI work with: the local App, or a
+// lazydocs server reached through remote.Client
+type Backend interface {
+	Config() config.Config
+
+	// Project is the project the docsets are scoped to, or nil
+	Project() *project.File
+
+	ListInstalledDocsets() ([]model.Docset, error)
+	ScopedDocsets() ([]model.Docset, error)
+
+	Search(query, docset, version string, limit, offset int) ([]model.SearchResult, error)
+	CountResults(query, docset, version string) (int, error)
+	SearchExamples(query, docset, version string, limit, offset int) ([]model.Example, error)
+	CountExamples(query, docset, version string) (int, error)
+	Suggest(query, docset, version string) (string, error)
+	FuzzySearch(query string, docsets []model.Docset, limit int) ([]model.FuzzyResult, error)
+	Related(entry model.Entry, allDocsets bool, limit int) ([]model.SearchResult, error)
+
+	ListEntries(docset, version string, after *model.Entry, limit int) ([]model.Entry, error)
+	CountEntries(docset, version string) (int, error)
+	GetEntry(docset, version, path string) (*model.Entry, error)
+	Headings(entryID int64) ([]model.Heading, error)
+	CompleteSymbols(slug, prefix string, limit int) ([]string, error)
+	ResolveEntry(slug, ref string) ([]model.Entry, error)
+	CompareEntry(slugA, slugB, ref string) (*model.Entry, *model.Entry, error)
+
+	// Managing docsets; a remote backend refuses these
+	ListAvailableDocsets(forceRefresh bool) (model.Manifest, error)
+	InstallDocset(slug string, progress data.ProgressCallback) error
+	RemoveDocset(slug string) error
+	OfferDocsets(dir string) ([]project.Suggestion, error)
+	MarkOffered(dir string) error
+
+	Close() error
+}
+
+var _ Backend = (*App)(nil)
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/lazydocs/lazydocs/internal/fuzzy"
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+// symbolCache keeps each docset's symbols in memory for fuzzy matching.
+// It is filled lazily and dropped for a docset when it is reinstalled
+// or removed.
+type symbolCache struct {
+	mu      sync.Mutex
+	docsets map[string]cachedSymbols
+}
+
+// cachedSymbols holds a docset's entries and their symbols, index aligned
+type cachedSymbols struct {
+	entries []model.Entry
+	symbols []string
+}
+
+// invalidate drops the cached symbols for a docset slug
+func (c *symbolCache) invalidate(slug string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.docsets, slug)
+}
+
+// FuzzySearch matches query fzf-style against the symbols of the given
+// docsets, best matches first
+func (a *App) FuzzySearch(query string, docsets []model.Docset, limit int) ([]model.FuzzyResult, error) {
+	if query == "" {
+		return nil, nil
+	}
+
+	var results []model.FuzzyResult
+	for _, ds := range docsets {
+		cached, err := a.docsetSymbols(ds)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range fuzzy.Find(query, cached.symbols, limit) {
+			results = append(results, model.FuzzyResult{
+				Entry:     cached.entries[r.Index],
+				Score:     r.Score,
+				Positions: r.Positions,
+			})
+		}
+	}
+
+	// Merge the per-docset matches
+	sortFuzzyResults(results)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// docsetSymbols returns the cached symbols for a docset, loading them on
+// first use
+func (a *App) docsetSymbols(ds model.Docset) (cachedSymbols, error) {
+	a.symbols.mu.Lock()
+	defer a.symbols.mu.Unlock()
+
+	if cached, ok := a.symbols.docsets[ds.Slug]; ok {
+		return cached, nil
+	}
+
+	entries, err := a.searcher.ListSymbols(ds.Name, ds.Version)
+	if err != nil {
+		return cachedSymbols{}, err
+	}
+
+	cached := cachedSymbols{
+		entries: entries,
+		symbols: make([]string, len(entries)),
+	}
+	for i, e := range entries {
+		cached.symbols[i] = e.Symbol
+	}
+
+	if a.symbols.docsets == nil {
+		a.symbols.docsets = make(map[string]cachedSymbols)
+	}
+	a.symbols.docsets[ds.Slug] = cached
+
+	return cached, nil
+}
+
+// sortFuzzyResults orders results by score, then by shorter symbol
+func sortFuzzyResults(results []model.FuzzyResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return len(results[i].Symbol) < len(results[j].Symbol)
+	})
+}
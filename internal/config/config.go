@@ -8,7 +8,8 @@ import (
 
 // Config holds user configuration
 type Config struct {
-	// Theme for markdown rendering: "dark", "light", "dracula", "notty"
+	// Theme for markdown rendering: "dark", "light", "dracula", "notty",
+	// another built-in glamour style or the path of a style file
 	Theme string `yaml:"theme"`
 
 	// DefaultDocsets are automatically selected on startup
@@ -16,6 +17,12 @@ type Config struct {
 
 	// UI customization
 	UI UIConfig `yaml:"ui"`
+
+	// Search ranking
+	Search SearchConfig `yaml:"search"`
+
+	// Shared index on a lazydocs server
+	Remote RemoteConfig `yaml:"remote,omitempty"`
 }
 
 // UIConfig holds UI-related settings
@@ -23,11 +30,36 @@ type UIConfig struct {
 	// Show debug info in status bar
 	ShowDebug bool `yaml:"show_debug"`
 
-	// Colors (hex or named)
+	// Colors (hex like "#ff8800" or an ANSI color number)
 	PrimaryColor   string `yaml:"primary_color,omitempty"`
 	SecondaryColor string `yaml:"secondary_color,omitempty"`
 }
 
+// SearchConfig tunes search ranking. Column weights scale BM25 scores;
+// boosts lift exact and prefix symbol matches above everything else.
+type SearchConfig struct {
+	SymbolWeight  float64 `yaml:"symbol_weight"`
+	TitleWeight   float64 `yaml:"title_weight"`
+	ContentWeight float64 `yaml:"content_weight"`
+	ExactBoost    float64 `yaml:"exact_boost"`
+	PrefixBoost   float64 `yaml:"prefix_boost"`
+
+	// Boosts for symbols containing the query at a word boundary
+	// (camelCase, snake_case, "." or "::") or anywhere at all
+	WordBoost      float64 `yaml:"word_boost"`
+	SubstringBoost float64 `yaml:"substring_boost"`
+}
+
+// RemoteConfig points the TUI and CLI at a "lazydocs serve" instance
+// instead of the local docsets
+type RemoteConfig struct {
+	// Server URL, e.g. "http://docs.lan:7280"; empty for local docsets
+	URL string `yaml:"url,omitempty"`
+
+	// Bearer token the server was started with, if any
+	Token string `yaml:"token,omitempty"`
+}
+
 // DefaultConfig returns the default configuration
 func DefaultConfig() Config {
 	return Config{
@@ -38,6 +70,15 @@ func DefaultConfig() Config {
 			PrimaryColor:   "",
 			SecondaryColor: "",
 		},
+		Search: SearchConfig{
+			SymbolWeight:   10,
+			TitleWeight:    5,
+			ContentWeight:  1,
+			ExactBoost:     1000,
+			PrefixBoost:    100,
+			WordBoost:      50,
+			SubstringBoost: 10,
+		},
 	}
 }
 
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Keys returns the dotted name of every setting, in declaration order
+func Keys() []string {
+	var keys []string
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			key := joinKey(prefix, yamlName(f))
+			if f.Type.Kind() == reflect.Struct {
+				walk(f.Type, key)
+			} else {
+				keys = append(keys, key)
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	return keys
+}
+
+// Get returns the value of a setting: strings as they are, anything else
+// as YAML
+func (c Config) Get(key string) (string, error) {
+	v, err := lookupKey(reflect.ValueOf(&c).Elem(), key)
+	if err != nil {
+		return "", err
+	}
+	if v.Kind() == reflect.String {
+		return v.String(), nil
+	}
+
+	out, err := yaml.Marshal(v.Interface())
+	if err != nil {
+		return "", fmt.Errorf("failed to format %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Set returns the config file data with key set to value, keeping comments
+// and the order of the other keys. Value is YAML, except for string
+// settings, where it is taken literally. It fails when the new value
+// wouldn't pass Validate.
+func Set(data []byte, key, value string) ([]byte, error) {
+	cfg := DefaultConfig()
+	field, err := lookupKey(reflect.ValueOf(&cfg).Elem(), key)
+	if err != nil {
+		return nil, err
+	}
+	if field.Kind() == reflect.Struct {
+		return nil, fmt.Errorf("%s is a section; set one of its keys", key)
+	}
+
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if field.Kind() != reflect.String {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(value), &doc); err != nil || len(doc.Content) == 0 {
+			return nil, fmt.Errorf("invalid value for %s: expected %s", key, typeName(field.Type()))
+		}
+		node = doc.Content[0]
+	}
+	if err := node.Decode(field.Addr().Interface()); err != nil {
+		return nil, fmt.Errorf("invalid value for %s: expected %s", key, typeName(field.Type()))
+	}
+
+	root, doc, err := parseMapping(data)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(key, ".")
+	for i, part := range parts[:len(parts)-1] {
+		child := mappingValue(root, part)
+		switch {
+		case child == nil:
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, child)
+		case child.Kind == yaml.ScalarNode && child.Tag == "!!null":
+			// "ui:" with nothing under it, say once its keys are commented out
+			*child = yaml.Node{Kind: yaml.MappingNode, HeadComment: child.HeadComment, LineComment: child.LineComment}
+		case child.Kind != yaml.MappingNode:
+			return nil, fmt.Errorf("line %d: %s: expected a mapping of keys to values", child.Line, strings.Join(parts[:i+1], "."))
+		}
+		root = child
+	}
+	if existing := mappingValue(root, parts[len(parts)-1]); existing != nil {
+		node.HeadComment, node.LineComment = existing.HeadComment, existing.LineComment
+		*existing = *node
+	} else {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: parts[len(parts)-1]}, node)
+	}
+
+	out, err := encode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only problems with this key or its sections matter; older mistakes
+	// elsewhere are for validate to report
+	problems, err := Validate(out)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range problems {
+		if p.Key == key || strings.HasPrefix(key, p.Key+".") {
+			return nil, fmt.Errorf("invalid value for %s: %s", key, p.Message)
+		}
+	}
+	return out, nil
+}
+
+// Unset returns the config file data without key, so it takes its default.
+// Sections left empty are removed too.
+func Unset(data []byte, key string) ([]byte, error) {
+	if _, err := lookupKey(reflect.ValueOf(&Config{}).Elem(), key); err != nil {
+		return nil, err
+	}
+
+	root, doc, err := parseMapping(data)
+	if err != nil {
+		return nil, err
+	}
+	removeKey(root, strings.Split(key, "."))
+	return encode(doc)
+}
+
+// removeKey deletes the dotted path parts from a mapping, pruning
+// mappings it empties
+func removeKey(node *yaml.Node, parts []string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != parts[0] {
+			continue
+		}
+		if len(parts) > 1 {
+			child := node.Content[i+1]
+			if child.Kind != yaml.MappingNode {
+				return
+			}
+			removeKey(child, parts[1:])
+			if len(child.Content) > 0 {
+				return
+			}
+		}
+		node.Content = append(node.Content[:i], node.Content[i+2:]...)
+		return
+	}
+}
+
+// parseMapping parses config file data, returning its top-level mapping and
+// the document holding it. Empty data gives an empty document.
+func parseMapping(data []byte) (*yaml.Node, *yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("line %d: expected a mapping of keys to values", root.Line)
+	}
+	return root, &doc, nil
+}
+
+// mappingValue returns the value for key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// encode writes a document with the two-space indent used in the README
+func encode(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// lookupKey returns the field of the Config value v named by a dotted key
+func lookupKey(v reflect.Value, key string) (reflect.Value, error) {
+	for _, part := range strings.Split(key, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("unknown key %q", key)
+		}
+		f, ok := fieldByName(v.Type(), part)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("unknown key %q", key)
+		}
+		v = v.FieldByIndex(f.Index)
+	}
+	return v, nil
+}
+
+// fieldByName returns the field of struct type t with the given YAML name
+func fieldByName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); yamlName(f) == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// yamlName returns the key a field is stored under
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	return name
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		key, value string
+		want       string // Resulting file; "" when Set should fail
+		wantErr    string
+	}{
+		{
+			name:  "new file",
+			key:   "theme",
+			value: "light",
+			want:  "theme: light\n",
+		},
+		{
+			name:  "keeps comments and order",
+			data:  "# My config\ntheme: dark # for the night\nui:\n  show_debug: false\n",
+			key:   "theme",
+			value: "light",
+			want:  "# My config\ntheme: light # for the night\nui:\n  show_debug: false\n",
+		},
+		{
+			name:  "new section",
+			data:  "theme: dark\n",
+			key:   "ui.primary_color",
+			value: "#fff",
+			want:  "theme: dark\nui:\n  primary_color: '#fff'\n",
+		},
+		{
+			name:  "empty section",
+			data:  "ui:\n",
+			key:   "ui.primary_color",
+			value: "#fff",
+			want:  "ui:\n  primary_color: '#fff'\n",
+		},
+		{
+			name:    "section that is a scalar",
+			data:    "ui: compact\n",
+			key:     "ui.primary_color",
+			value:   "#fff",
+			wantErr: "line 1: ui: expected a mapping",
+		},
+		{
+			name:    "wrong type",
+			key:     "search.exact_boost",
+			value:   "lots",
+			wantErr: "expected a number",
+		},
+		{
+			name:    "invalid value",
+			key:     "ui.primary_color",
+			value:   "#ff88",
+			wantErr: "malformed hex color",
+		},
+		{
+			name:    "section as key",
+			key:     "ui",
+			value:   "x",
+			wantErr: "is a section",
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := Set([]byte(tt.data), tt.key, tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: Set error = %v; want it to contain %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Set: %v", tt.name, err)
+			continue
+		}
+		if string(out) != tt.want {
+			t.Errorf("%s: Set =\n%s\nwant\n%s", tt.name, out, tt.want)
+		}
+	}
+}
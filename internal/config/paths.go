@@ -7,24 +7,36 @@ import (
 
 // Paths holds all the filesystem paths used by lazydocs
 type Paths struct {
-	DataDir     string // Base data directory
-	DocsDir     string // Where docsets are stored
-	DBPath      string // SQLite database path
+	DataDir      string // Base data directory
+	DocsDir      string // Where docsets are stored
+	DBPath       string // SQLite database path
 	ManifestPath string // Cached manifest path
-	ConfigPath  string // User configuration path
+	ConfigPath   string // User configuration path
+	OfferedPath  string // Projects already offered their detected docsets
 }
 
 // DefaultPaths returns the default paths following XDG conventions
 func DefaultPaths() Paths {
-	dataDir := getDataDir()
-	configDir := getConfigDir()
+	return NewPaths("", "")
+}
+
+// NewPaths returns the paths for a data directory and config file, as set
+// by the --data-dir and --config flags. Empty values use the defaults.
+func NewPaths(dataDir, configPath string) Paths {
+	if dataDir == "" {
+		dataDir = getDataDir()
+	}
+	if configPath == "" {
+		configPath = filepath.Join(getConfigDir(), "config.yaml")
+	}
 
 	return Paths{
 		DataDir:      dataDir,
 		DocsDir:      filepath.Join(dataDir, "docs"),
 		DBPath:       filepath.Join(dataDir, "index.sqlite"),
 		ManifestPath: filepath.Join(dataDir, "manifest.json"),
-		ConfigPath:   filepath.Join(configDir, "config.yaml"),
+		ConfigPath:   configPath,
+		OfferedPath:  filepath.Join(dataDir, "offered.json"),
 	}
 }
 
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/lazydocs/lazydocs/internal/fuzzy"
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is a mistake found in a config file
+type Problem struct {
+	Line    int    // 1-based line of the offending key or value
+	Key     string // Dotted key, e.g. "ui.primary_color"
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("line %d: %s: %s", p.Line, p.Key, p.Message)
+}
+
+// hexColor matches #rgb and #rrggbb
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// valueChecks hold extra checks for keys whose type alone doesn't rule out
+// bad values. Each returns a message, or "" when the value is fine.
+var valueChecks = map[string]func(v any) string{
+	"theme":              checkTheme,
+	"ui.primary_color":   checkColor,
+	"ui.secondary_color": checkColor,
+	"remote.url":         checkURL,
+
+	"search.symbol_weight":   checkWeight,
+	"search.title_weight":    checkWeight,
+	"search.content_weight":  checkWeight,
+	"search.exact_boost":     checkBoost,
+	"search.prefix_boost":    checkBoost,
+	"search.word_boost":      checkBoost,
+	"search.substring_boost": checkBoost,
+}
+
+// Validate checks the YAML of a config file for unknown keys, values of the
+// wrong type, unknown themes, malformed colors and out of range ranking
+// weights. The error is for YAML that can't be parsed at all, and carries
+// its own line number.
+func Validate(data []byte) ([]Problem, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	var problems []Problem
+	checkMapping(doc.Content[0], reflect.TypeOf(Config{}), "", &problems)
+	return problems, nil
+}
+
+// checkMapping checks node against the fields of the struct type t
+func checkMapping(node *yaml.Node, t reflect.Type, prefix string, problems *[]Problem) {
+	if node.Kind != yaml.MappingNode {
+		name := prefix
+		if name == "" {
+			name = "(top level)"
+		}
+		*problems = append(*problems, Problem{node.Line, name, "expected a mapping of keys to values"})
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		key := joinKey(prefix, k.Value)
+
+		f, ok := fieldByName(t, k.Value)
+		if !ok {
+			msg := "unknown key"
+			if s := closestKey(t, k.Value); s != "" {
+				msg += fmt.Sprintf("; did you mean %q?", joinKey(prefix, s))
+			}
+			*problems = append(*problems, Problem{k.Line, key, msg})
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Struct {
+			checkMapping(v, f.Type, key, problems)
+			continue
+		}
+
+		value := reflect.New(f.Type)
+		if err := v.Decode(value.Interface()); err != nil {
+			*problems = append(*problems, Problem{v.Line, key, "expected " + typeName(f.Type)})
+			continue
+		}
+		if check := valueChecks[key]; check != nil {
+			if msg := check(value.Elem().Interface()); msg != "" {
+				*problems = append(*problems, Problem{v.Line, key, msg})
+			}
+		}
+	}
+}
+
+// Themes returns the names of the built-in rendering themes
+func Themes() []string {
+	names := []string{styles.AutoStyle}
+	for name := range styles.DefaultStyles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// checkTheme accepts a built-in theme or the path of a glamour style file
+func checkTheme(v any) string {
+	theme := v.(string)
+	if theme == "" || slices.Contains(Themes(), theme) {
+		return ""
+	}
+	if _, err := os.Stat(theme); err == nil {
+		return ""
+	}
+	return fmt.Sprintf("unknown theme %q; use one of %s, or the path of a glamour style file",
+		theme, strings.Join(Themes(), ", "))
+}
+
+// checkColor accepts a hex color or an ANSI color number
+func checkColor(v any) string {
+	color := v.(string)
+	switch {
+	case color == "", hexColor.MatchString(color):
+		return ""
+	case strings.HasPrefix(color, "#"):
+		return fmt.Sprintf("malformed hex color %q; use #rgb or #rrggbb", color)
+	}
+	if n, err := strconv.Atoi(color); err == nil && n >= 0 && n <= 255 {
+		return ""
+	}
+	return fmt.Sprintf("invalid color %q; use a hex color like #ff8800 or an ANSI color number 0-255", color)
+}
+
+// checkWeight accepts a positive BM25 column weight. Zero would drop the
+// column from ranking and a negative weight would rank matches last.
+func checkWeight(v any) string {
+	if w := v.(float64); w <= 0 {
+		return fmt.Sprintf("weight %v must be greater than 0", w)
+	}
+	return ""
+}
+
+// checkBoost accepts a boost of 0 or more; 0 turns the boost off
+func checkBoost(v any) string {
+	if b := v.(float64); b < 0 {
+		return fmt.Sprintf("boost %v must not be negative", b)
+	}
+	return ""
+}
+
+// checkURL accepts an http or https URL with a host
+func checkURL(v any) string {
+	raw := v.(string)
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Sprintf("invalid URL %q; use http://host:port", raw)
+	}
+	return ""
+}
+
+// closestKey returns the field of t whose YAML name is a likely typo of
+// name, or ""
+func closestKey(t reflect.Type, name string) string {
+	best, bestDist := "", 3
+	for i := 0; i < t.NumField(); i++ {
+		candidate := yamlName(t.Field(i))
+		if dist := fuzzy.EditDistance(name, candidate); dist < bestDist {
+			best, bestDist = candidate, dist
+		}
+	}
+	return best
+}
+
+// typeName describes a field type in user terms
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Float32, reflect.Float64, reflect.Int, reflect.Int64:
+		return "a number"
+	case reflect.Slice:
+		return "a list"
+	default:
+		return "a string"
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string // Problems as "line N: key: message" prefixes
+	}{
+		{name: "empty", yaml: ""},
+		{name: "defaults", yaml: "theme: dark\nui:\n  show_debug: false\nsearch:\n  exact_boost: 500\n"},
+		{name: "weights", yaml: "search:\n  symbol_weight: 10\n  title_weight: 0.5\n  content_weight: 1\n"},
+		{name: "colors", yaml: "ui:\n  primary_color: \"#ff8800\"\n  secondary_color: \"212\"\n"},
+		{name: "remote", yaml: "remote:\n  url: http://docs.lan:7280\n  token: s3cret\n"},
+		{
+			name: "unknown key with suggestion",
+			yaml: "theme: dark\nthem: light\n",
+			want: []string{`line 2: them: unknown key; did you mean "theme"?`},
+		},
+		{
+			name: "nested unknown key",
+			yaml: "ui:\n  show_debugs: true\n",
+			want: []string{`line 2: ui.show_debugs: unknown key; did you mean "ui.show_debug"?`},
+		},
+		{
+			name: "wrong type",
+			yaml: "search:\n  exact_boost: lots\n",
+			want: []string{"line 2: search.exact_boost:"},
+		},
+		{
+			name: "unknown theme",
+			yaml: "theme: solarized-ish\n",
+			want: []string{"line 1: theme:"},
+		},
+		{
+			name: "malformed color",
+			yaml: "ui:\n  primary_color: \"#ff88\"\n",
+			want: []string{"line 2: ui.primary_color:"},
+		},
+		{
+			name: "remote url without scheme",
+			yaml: "remote:\n  url: docs.lan:7280\n",
+			want: []string{"line 2: remote.url:"},
+		},
+		{
+			name: "zero weight",
+			yaml: "search:\n  symbol_weight: 0\n",
+			want: []string{"line 2: search.symbol_weight: weight 0 must be greater than 0"},
+		},
+		{
+			name: "negative weights",
+			yaml: "search:\n  title_weight: -1\n  content_weight: -0.5\n",
+			want: []string{"line 2: search.title_weight:", "line 3: search.content_weight:"},
+		},
+		{
+			name: "negative boost",
+			yaml: "search:\n  prefix_boost: -10\n  word_boost: 0\n",
+			want: []string{"line 2: search.prefix_boost: boost -10 must not be negative"},
+		},
+		{
+			name: "section that isn't a mapping",
+			yaml: "ui: compact\n",
+			want: []string{"line 1: ui:"},
+		},
+	}
+
+	for _, tt := range tests {
+		problems, err := Validate([]byte(tt.yaml))
+		if err != nil {
+			t.Errorf("%s: Validate: %v", tt.name, err)
+			continue
+		}
+		if len(problems) != len(tt.want) {
+			t.Errorf("%s: Validate = %q; want %d problems", tt.name, problems, len(tt.want))
+			continue
+		}
+		for i, p := range problems {
+			if !strings.HasPrefix(p.String(), tt.want[i]) {
+				t.Errorf("%s: problem %d = %q; want it to start with %q", tt.name, i, p, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestValidateSyntaxError(t *testing.T) {
+	if _, err := Validate([]byte("theme: [dark\n")); err == nil {
+		t.Error("Validate of malformed YAML returned no error")
+	}
+}
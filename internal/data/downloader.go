@@ -60,6 +60,66 @@ func (d *Downloader) Download(entry model.ManifestEntry, progress ProgressCallba
 		return fmt.Errorf("failed to fetch index: %w", err)
 	}
 
+	// Keep the index next to the raw data so the docset can be rebuilt offline
+	if err := d.saveIndex(entry.Slug, indexData); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+
+	if err := d.index(entry, data, indexData, false, progress); err != nil {
+		return err
+	}
+
+	if progress != nil {
+		progress(entry.DBSize, entry.DBSize, "Done")
+	}
+
+	return nil
+}
+
+// Reindex rebuilds an installed docset's index from the raw files on disk.
+// Docsets installed before the index was kept locally fetch it once.
+func (d *Downloader) Reindex(docset model.Docset, progress ProgressCallback) error {
+	data, err := d.storage.LoadDocset(docset.Slug)
+	if err != nil {
+		return fmt.Errorf("failed to load docset: %w", err)
+	}
+
+	var indexData *DocsetData
+	if raw, err := d.storage.LoadIndex(docset.Slug); err == nil {
+		indexData = &DocsetData{}
+		if err := json.Unmarshal(raw, indexData); err != nil {
+			return fmt.Errorf("failed to parse index: %w", err)
+		}
+	} else {
+		indexData, err = d.client.FetchIndex(docset.Slug)
+		if err != nil {
+			return fmt.Errorf("failed to fetch index: %w", err)
+		}
+		if err := d.saveIndex(docset.Slug, indexData); err != nil {
+			return fmt.Errorf("failed to save index: %w", err)
+		}
+	}
+
+	entry := model.ManifestEntry{
+		Name:  docset.DisplayName,
+		Slug:  docset.Slug,
+		Mtime: docset.Mtime,
+	}
+
+	if err := d.index(entry, data, indexData, true, progress); err != nil {
+		return err
+	}
+
+	if progress != nil {
+		progress(0, 0, "Done")
+	}
+
+	return nil
+}
+
+// index parses, converts and indexes raw docset data. A rebuild indexes a
+// release that is already installed.
+func (d *Downloader) index(entry model.ManifestEntry, data []byte, indexData *DocsetData, rebuild bool, progress ProgressCallback) error {
 	// Parse and convert the content
 	entries, err := d.parseDocset(entry, data, indexData)
 	if err != nil {
@@ -81,17 +141,26 @@ func (d *Downloader) Download(entry model.ManifestEntry, progress ProgressCallba
 		Mtime:       entry.Mtime,
 	}
 
-	if err := d.indexer.IndexDocset(docset, entries); err != nil {
-		return fmt.Errorf("failed to index docset: %w", err)
+	index := d.indexer.IndexDocset
+	if rebuild {
+		index = d.indexer.RebuildDocset
 	}
-
-	if progress != nil {
-		progress(entry.DBSize, entry.DBSize, "Done")
+	if err := index(docset, entries); err != nil {
+		return fmt.Errorf("failed to index docset: %w", err)
 	}
 
 	return nil
 }
 
+// saveIndex writes the docset index to storage
+func (d *Downloader) saveIndex(slug string, indexData *DocsetData) error {
+	raw, err := json.Marshal(indexData)
+	if err != nil {
+		return err
+	}
+	return d.storage.SaveIndex(slug, raw)
+}
+
 // parseDocset parses the raw docset JSON and converts HTML to markdown
 func (d *Downloader) parseDocset(manifest model.ManifestEntry, data []byte, indexData *DocsetData) ([]model.Entry, error) {
 	// DevDocs db.json format is a map of path -> HTML content
@@ -135,6 +204,7 @@ func (d *Downloader) parseDocset(manifest model.ManifestEntry, data []byte, inde
 			Docset:  name,
 			Version: version,
 			Symbol:  symbol,
+			Type:    meta.Type,
 			Title:   title,
 			Content: markdown,
 			Path:    path,
@@ -59,6 +59,16 @@ func (mc *ManifestCache) Get(forceRefresh bool) (model.Manifest, error) {
 	return manifest, nil
 }
 
+// Cached returns the manifest saved on disk, however old, without going to
+// the network. It fails when the manifest has never been fetched.
+func (mc *ManifestCache) Cached() (model.Manifest, error) {
+	cached, err := mc.loadFromDisk()
+	if err != nil {
+		return nil, err
+	}
+	return cached.Manifest, nil
+}
+
 // Find finds a docset by slug in the manifest
 func (mc *ManifestCache) Find(slug string) *model.ManifestEntry {
 	for i := range mc.manifest {
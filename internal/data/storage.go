@@ -37,6 +37,26 @@ func (s *Storage) LoadDocset(slug string) ([]byte, error) {
 	return os.ReadFile(path)
 }
 
+// SaveIndex saves the raw docset index (entry names and types) to disk
+func (s *Storage) SaveIndex(slug string, data []byte) error {
+	name, version := model.ParseSlug(slug)
+
+	dir := s.docsetDir(name, version)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, "index.json")
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadIndex loads the raw docset index from disk
+func (s *Storage) LoadIndex(slug string) ([]byte, error) {
+	name, version := model.ParseSlug(slug)
+	path := filepath.Join(s.docsetDir(name, version), "index.json")
+	return os.ReadFile(path)
+}
+
 // DeleteDocset removes a docset from disk
 func (s *Storage) DeleteDocset(slug string) error {
 	name, version := model.ParseSlug(slug)
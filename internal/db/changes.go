@@ -0,0 +1,154 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+// ErrNoSnapshot is returned when a docset has no previous install to
+// compare against
+var ErrNoSnapshot = errors.New("no previous install recorded")
+
+// symbolState is what a docset version holds for one symbol
+type symbolState struct {
+	change model.SymbolChange
+	digest string // Digests of every entry with the symbol, joined
+}
+
+// contentDigest fingerprints entry content for change detection
+func contentDigest(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:16])
+}
+
+// snapshot records the fingerprints of a docset's current entries,
+// replacing any earlier snapshot. Entries indexed before digests existed
+// are skipped, since they'd compare as changed.
+func snapshot(tx *sql.Tx, docset, version string) error {
+	_, err := tx.Exec("DELETE FROM snapshots WHERE docset = ? AND version = ?", docset, version)
+	if err != nil {
+		return fmt.Errorf("failed to clear snapshot: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO snapshots (docset, version, symbol, type, path, digest)
+		SELECT docset, version, symbol, type, path, digest
+		FROM entries
+		WHERE docset = ? AND version = ? AND digest != ''
+	`, docset, version)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot entries: %w", err)
+	}
+	return nil
+}
+
+// CompareVersions lists the symbols added, removed and changed going from
+// one installed docset version to another
+func (s *Searcher) CompareVersions(fromDocset, fromVersion, toDocset, toVersion string) (*model.Changelog, error) {
+	from, err := s.symbolStates("entries", fromDocset, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.symbolStates("entries", toDocset, toVersion)
+	if err != nil {
+		return nil, err
+	}
+	return compareStates(from, to), nil
+}
+
+// ChangesSinceUpdate lists the symbols added, removed and changed by the
+// latest install of a docset, compared with the install it replaced
+func (s *Searcher) ChangesSinceUpdate(docset, version string) (*model.Changelog, error) {
+	from, err := s.symbolStates("snapshots", docset, version)
+	if err != nil {
+		return nil, err
+	}
+	if len(from) == 0 {
+		return nil, ErrNoSnapshot
+	}
+	to, err := s.symbolStates("entries", docset, version)
+	if err != nil {
+		return nil, err
+	}
+	return compareStates(from, to), nil
+}
+
+// symbolStates loads the symbols of a docset version from entries or
+// snapshots
+func (s *Searcher) symbolStates(table, docset, version string) (map[string]symbolState, error) {
+	rows, err := s.db.conn.Query(`
+		SELECT symbol, type, path, digest
+		FROM `+table+`
+		WHERE docset = ? AND version = ?
+		ORDER BY symbol, path
+	`, docset, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load symbols: %w", err)
+	}
+	defer rows.Close()
+
+	states := make(map[string]symbolState)
+	for rows.Next() {
+		var c model.SymbolChange
+		var digest string
+		if err := rows.Scan(&c.Symbol, &c.Type, &c.Path, &digest); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol: %w", err)
+		}
+
+		// A symbol can span several entries; the first path represents it
+		if st, ok := states[c.Symbol]; ok {
+			st.digest += "," + digest
+			states[c.Symbol] = st
+			continue
+		}
+		states[c.Symbol] = symbolState{change: c, digest: digest}
+	}
+
+	return states, rows.Err()
+}
+
+// compareStates diffs two sets of symbols
+func compareStates(from, to map[string]symbolState) *model.Changelog {
+	c := &model.Changelog{
+		Added:   []model.SymbolChange{},
+		Removed: []model.SymbolChange{},
+		Changed: []model.SymbolChange{},
+	}
+	for sym, cur := range to {
+		old, ok := from[sym]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, cur.change)
+		case old.digest != cur.digest:
+			c.Changed = append(c.Changed, cur.change)
+		}
+	}
+	for sym, old := range from {
+		if _, ok := to[sym]; !ok {
+			c.Removed = append(c.Removed, old.change)
+		}
+	}
+
+	for _, list := range [][]model.SymbolChange{c.Added, c.Removed, c.Changed} {
+		// Symbols differing only in case, like "Map" and "map", fall
+		// back to byte order so the list is the same every run
+		sort.Slice(list, func(i, j int) bool {
+			a, b := list[i], list[j]
+			if la, lb := strings.ToLower(a.Symbol), strings.ToLower(b.Symbol); la != lb {
+				return la < lb
+			}
+			if a.Symbol != b.Symbol {
+				return a.Symbol < b.Symbol
+			}
+			return a.Type < b.Type
+		})
+	}
+	return c
+}
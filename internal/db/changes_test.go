@@ -0,0 +1,59 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+func TestRebuildKeepsUpdateSnapshot(t *testing.T) {
+	database := openFixture(t,
+		entry("Array.prototype.map", "array/map", "# map\n\nOld text."),
+		entry("Array.prototype.filter", "array/filter", "# filter"),
+	)
+	idx := NewIndexer(database)
+	docset := model.Docset{Slug: "js", Name: "js", DisplayName: "JavaScript"}
+	updated := []model.Entry{
+		{Docset: "js", Symbol: "Array.prototype.map", Path: "array/map", Content: "# map\n\nNew text."},
+		{Docset: "js", Symbol: "Array.prototype.flat", Path: "array/flat", Content: "# flat"},
+	}
+
+	if err := idx.IndexDocset(docset, updated); err != nil {
+		t.Fatal(err)
+	}
+	// A schema upgrade rebuilds the same release
+	if err := idx.RebuildDocset(docset, updated); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewSearcher(database).ChangesSinceUpdate("js", "")
+	if err != nil {
+		t.Fatalf("ChangesSinceUpdate: %v", err)
+	}
+	if len(c.Added) != 1 || len(c.Removed) != 1 || len(c.Changed) != 1 {
+		t.Errorf("after a rebuild, changes = %+v; want the update's 1 added, 1 removed, 1 changed", c)
+	}
+}
+
+func TestCompareStatesOrder(t *testing.T) {
+	to := make(map[string]symbolState)
+	for _, sym := range []string{"map", "Map", "filter", "MAP"} {
+		to[sym] = symbolState{change: model.SymbolChange{Symbol: sym}}
+	}
+
+	// Map iteration order varies, so sort several times
+	want := "filter MAP Map map"
+	for range 10 {
+		c := compareStates(nil, to)
+		var got []string
+		for _, s := range c.Added {
+			got = append(got, s.Symbol)
+		}
+		if strings.Join(got, " ") != want {
+			t.Fatalf("Added = %q; want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+// extractExamples returns the fenced code blocks of Markdown content in
+// order. Blocks that are empty after trimming are skipped.
+func extractExamples(markdown string) []model.Example {
+	var examples []model.Example
+	var code []string
+	var language string
+	fence := ""
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			if fence = openingFence(trimmed); fence != "" {
+				language, _, _ = strings.Cut(strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1])), " ")
+				code = code[:0]
+			}
+			continue
+		}
+
+		if closesFence(trimmed, fence) {
+			if block := strings.Trim(strings.Join(code, "\n"), "\n"); strings.TrimSpace(block) != "" {
+				examples = append(examples, model.Example{Language: language, Code: block})
+			}
+			fence = ""
+			continue
+		}
+		code = append(code, line)
+	}
+	return examples
+}
+
+// openingFence returns the run of three or more backticks or tildes that
+// a trimmed line opens a code block with, or "" if it opens none
+func openingFence(trimmed string) string {
+	if !strings.HasPrefix(trimmed, "```") && !strings.HasPrefix(trimmed, "~~~") {
+		return ""
+	}
+	return trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+}
+
+// closesFence reports whether a trimmed line closes the block fence opened:
+// nothing but the same character, at least as many times. Shorter fences
+// and ones with a language, such as Markdown showing Markdown, are code.
+func closesFence(trimmed, fence string) bool {
+	return len(trimmed) >= len(fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// SearchExamples searches code examples only, returning up to limit blocks
+// starting at offset. It takes the same query syntax and arguments as
+// Search; symbol: terms match code like any other term.
+func (s *Searcher) SearchExamples(query string, docset string, version string, limit, offset int) ([]model.Example, error) {
+	if query == "" {
+		return nil, nil
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := q.filters(docset, version, s.scope)
+	args = append(args,
+		sql.Named("query", q.CodeMatchExpr()),
+		sql.Named("limit", limit),
+		sql.Named("offset", offset),
+	)
+
+	rows, err := s.db.conn.Query(`
+		SELECT
+			x.id,
+			x.position,
+			x.language,
+			x.code,
+			e.id,
+			e.docset,
+			e.version,
+			e.symbol,
+			e.type,
+			e.title,
+			e.path,
+			bm25(examples_fts) AS rank
+		FROM examples_fts
+		JOIN examples x ON x.id = examples_fts.rowid
+		JOIN entries e ON e.id = x.entry_id
+		WHERE examples_fts MATCH :query`+where+`
+		ORDER BY rank, x.id
+		LIMIT :limit OFFSET :offset
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("example search failed: %w", err)
+	}
+	defer rows.Close()
+
+	var examples []model.Example
+	for rows.Next() {
+		var x model.Example
+		e := &x.Entry
+		err := rows.Scan(
+			&x.ID, &x.Position, &x.Language, &x.Code,
+			&e.ID, &e.Docset, &e.Version, &e.Symbol, &e.Type, &e.Title, &e.Path,
+			&x.Rank,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan example: %w", err)
+		}
+		examples = append(examples, x)
+	}
+
+	return examples, rows.Err()
+}
+
+// CountExamples returns the total number of code examples matching a
+// search, taking the same arguments as SearchExamples
+func (s *Searcher) CountExamples(query string, docset string, version string) (int, error) {
+	if query == "" {
+		return 0, nil
+	}
+
+	q, err := ParseQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	where, args := q.filters(docset, version, s.scope)
+	args = append(args, sql.Named("query", q.CodeMatchExpr()))
+
+	var count int
+	err = s.db.conn.QueryRow(`
+		SELECT count(*)
+		FROM examples_fts
+		JOIN examples x ON x.id = examples_fts.rowid
+		JOIN entries e ON e.id = x.entry_id
+		WHERE examples_fts MATCH :query`+where, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count query failed: %w", err)
+	}
+	return count, nil
+}
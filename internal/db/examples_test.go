@@ -0,0 +1,54 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+func TestExtractExamples(t *testing.T) {
+	md := "# Fences\n\n" +
+		"```js\nlet a = 1;\n```\n\n" +
+		"````markdown\nUse:\n\n```go\nfmt.Println()\n```\n````\n\n" +
+		"```\n\n   \n```\n" // Empty, so skipped
+
+	want := []model.Example{
+		{Language: "js", Code: "let a = 1;"},
+		{Language: "markdown", Code: "Use:\n\n```go\nfmt.Println()\n```"},
+	}
+	got := extractExamples(md)
+	if len(got) != len(want) {
+		t.Fatalf("extractExamples = %+v; want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Language != want[i].Language || got[i].Code != want[i].Code {
+			t.Errorf("example %d = %+v; want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFences(t *testing.T) {
+	tests := []struct {
+		line   string
+		fence  string
+		closes bool // Whether the line closes a ``` block
+	}{
+		{"```", "```", true},
+		{"````", "````", true},
+		{"```go", "```", false},
+		{"~~~", "~~~", false},
+		{"``", "", false},
+		{"text", "", false},
+	}
+	for _, tt := range tests {
+		if got := openingFence(tt.line); got != tt.fence {
+			t.Errorf("openingFence(%q) = %q; want %q", tt.line, got, tt.fence)
+		}
+		if got := closesFence(tt.line, "```"); got != tt.closes {
+			t.Errorf("closesFence(%q, ```) = %v; want %v", tt.line, got, tt.closes)
+		}
+	}
+	if closesFence("```", "````") {
+		t.Error("a shorter fence closed a ```` block")
+	}
+}
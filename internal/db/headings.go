@@ -0,0 +1,73 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+var (
+	// atxHeading matches "#" to "####" headings
+	atxHeading = regexp.MustCompile(`^ {0,3}(#{1,4})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+
+	// mdLink and mdEmphasis match inline Markdown stripped from heading text
+	mdLink     = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	mdEmphasis = regexp.MustCompile("[*_`]+")
+)
+
+// extractHeadings returns the H1-H4 headings of Markdown content in order,
+// skipping anything inside fenced code blocks
+func extractHeadings(markdown string) []model.Heading {
+	var headings []model.Heading
+	fence := ""
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if closesFence(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if fence = openingFence(trimmed); fence != "" {
+			continue
+		}
+
+		m := atxHeading.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		text := mdLink.ReplaceAllString(m[2], "$1")
+		text = strings.TrimSpace(mdEmphasis.ReplaceAllString(text, ""))
+		if text == "" {
+			continue
+		}
+		headings = append(headings, model.Heading{Level: len(m[1]), Text: text})
+	}
+	return headings
+}
+
+// Headings returns the headings of an entry in document order
+func (s *Searcher) Headings(entryID int64) ([]model.Heading, error) {
+	rows, err := s.db.conn.Query(`
+		SELECT level, text FROM headings
+		WHERE entry_id = ?
+		ORDER BY position
+	`, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("headings query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var headings []model.Heading
+	for rows.Next() {
+		var h model.Heading
+		if err := rows.Scan(&h.Level, &h.Text); err != nil {
+			return nil, fmt.Errorf("failed to scan heading: %w", err)
+		}
+		headings = append(headings, h)
+	}
+
+	return headings, rows.Err()
+}
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+func TestExtractHeadings(t *testing.T) {
+	md := "# Writing docs\n\n" +
+		"````markdown\n# Not a heading\n```go\nfunc f() {}\n```\n## Still code\n````\n\n" +
+		"## After the [example](#x)\n\n" +
+		"~~~\n### In a tilde block\n~~~\n" +
+		"#### *Last*\n"
+
+	want := []model.Heading{
+		{Level: 1, Text: "Writing docs"},
+		{Level: 2, Text: "After the example"},
+		{Level: 4, Text: "Last"},
+	}
+	got := extractHeadings(md)
+	if len(got) != len(want) {
+		t.Fatalf("extractHeadings = %+v; want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("heading %d = %+v; want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -16,17 +16,35 @@ func NewIndexer(db *DB) *Indexer {
 	return &Indexer{db: db}
 }
 
-// IndexDocset indexes all entries for a docset
+// IndexDocset indexes all entries for a freshly installed or updated
+// docset, keeping fingerprints of what it replaces for ChangesSinceUpdate
 func (idx *Indexer) IndexDocset(docset model.Docset, entries []model.Entry) error {
+	return idx.index(docset, entries, true)
+}
+
+// RebuildDocset indexes a docset again from the same release, as after a
+// schema upgrade. The snapshot of the install it replaced is kept, since
+// this one is no newer.
+func (idx *Indexer) RebuildDocset(docset model.Docset, entries []model.Entry) error {
+	return idx.index(docset, entries, false)
+}
+
+func (idx *Indexer) index(docset model.Docset, entries []model.Entry, update bool) error {
 	tx, err := idx.db.conn.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	if update {
+		if err := snapshot(tx, docset.Name, docset.Version); err != nil {
+			return err
+		}
+	}
+
 	// First, remove any existing entries for this docset
 	_, err = tx.Exec(
-		"DELETE FROM docs WHERE docset = ? AND version = ?",
+		"DELETE FROM entries WHERE docset = ? AND version = ?",
 		docset.Name, docset.Version,
 	)
 	if err != nil {
@@ -35,27 +53,63 @@ func (idx *Indexer) IndexDocset(docset model.Docset, entries []model.Entry) erro
 
 	// Prepare insert statement
 	stmt, err := tx.Prepare(`
-		INSERT INTO docs (docset, version, symbol, title, content, path)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO entries (docset, version, symbol, symbol_words, type, title, content, path, digest)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare insert statement: %w", err)
 	}
 	defer stmt.Close()
 
+	headingStmt, err := tx.Prepare(`
+		INSERT INTO headings (entry_id, position, level, text)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare heading statement: %w", err)
+	}
+	defer headingStmt.Close()
+
+	exampleStmt, err := tx.Prepare(`
+		INSERT INTO examples (entry_id, position, language, code)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare example statement: %w", err)
+	}
+	defer exampleStmt.Close()
+
 	// Insert all entries
 	for _, entry := range entries {
-		_, err = stmt.Exec(
+		res, err := stmt.Exec(
 			entry.Docset,
 			entry.Version,
 			entry.Symbol,
+			symbolWords(entry.Symbol),
+			entry.Type,
 			entry.Title,
 			entry.Content,
 			entry.Path,
+			contentDigest(entry.Content),
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert entry %s: %w", entry.Symbol, err)
 		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get entry id: %w", err)
+		}
+		for i, h := range extractHeadings(entry.Content) {
+			if _, err := headingStmt.Exec(id, i, h.Level, h.Text); err != nil {
+				return fmt.Errorf("failed to insert headings of %s: %w", entry.Symbol, err)
+			}
+		}
+		for i, ex := range extractExamples(entry.Content) {
+			if _, err := exampleStmt.Exec(id, i, ex.Language, ex.Code); err != nil {
+				return fmt.Errorf("failed to insert examples of %s: %w", entry.Symbol, err)
+			}
+		}
 	}
 
 	// Insert or update docset metadata
@@ -66,6 +120,7 @@ func (idx *Indexer) IndexDocset(docset model.Docset, entries []model.Entry) erro
 			display_name = excluded.display_name,
 			entry_count = excluded.entry_count,
 			mtime = excluded.mtime,
+			needs_reindex = 0,
 			installed_at = strftime('%s', 'now')
 	`, docset.Slug, docset.Name, docset.Version, docset.DisplayName, len(entries), docset.Mtime)
 	if err != nil {
@@ -86,11 +141,16 @@ func (idx *Indexer) RemoveDocset(slug string) error {
 	defer tx.Rollback()
 
 	// Remove entries
-	_, err = tx.Exec("DELETE FROM docs WHERE docset = ? AND version = ?", name, version)
+	_, err = tx.Exec("DELETE FROM entries WHERE docset = ? AND version = ?", name, version)
 	if err != nil {
 		return fmt.Errorf("failed to delete entries: %w", err)
 	}
 
+	_, err = tx.Exec("DELETE FROM snapshots WHERE docset = ? AND version = ?", name, version)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+
 	// Remove docset metadata
 	_, err = tx.Exec("DELETE FROM docsets WHERE slug = ?", slug)
 	if err != nil {
@@ -99,3 +159,30 @@ func (idx *Indexer) RemoveDocset(slug string) error {
 
 	return tx.Commit()
 }
+
+// PendingReindex returns docsets that a schema migration flagged for a
+// rebuild from their raw files
+func (idx *Indexer) PendingReindex() ([]model.Docset, error) {
+	rows, err := idx.db.conn.Query(`
+		SELECT id, slug, name, version, display_name, entry_count, mtime
+		FROM docsets
+		WHERE needs_reindex = 1
+		ORDER BY name, version DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list docsets pending reindex: %w", err)
+	}
+	defer rows.Close()
+
+	var docsets []model.Docset
+	for rows.Next() {
+		var d model.Docset
+		err := rows.Scan(&d.ID, &d.Slug, &d.Name, &d.Version, &d.DisplayName, &d.EntryCount, &d.Mtime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan docset: %w", err)
+		}
+		docsets = append(docsets, d)
+	}
+
+	return docsets, rows.Err()
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrSchemaTooNew is returned when the database was written by a newer
+// version of lazydocs than the one running
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of lazydocs")
+
+// migration is a single schema change. Migrations run in order, each in
+// its own transaction, and bump PRAGMA user_version to their version.
+type migration struct {
+	version int
+	name    string
+	sql     string
+
+	// reindex marks every installed docset for a rebuild from its raw
+	// files, for changes (such as a new FTS table shape) that can't be
+	// carried over with SQL alone
+	reindex bool
+
+	// vacuum reclaims free pages afterwards, for migrations that drop
+	// large tables
+	vacuum bool
+}
+
+// migrations is the ordered list of schema changes. Never edit or reorder
+// an entry once released; append a new one instead.
+var migrations = []migration{
+	{version: 1, name: "initial schema", sql: schemaV1},
+	{version: 2, name: "docset reindex flag", sql: schemaV2},
+	{version: 3, name: "external content index", sql: schemaV3, vacuum: true},
+	{version: 4, name: "symbol index", sql: schemaV4, reindex: true},
+	{version: 5, name: "entry types", sql: schemaV5, reindex: true},
+	{version: 6, name: "spelling vocabulary", sql: schemaV6},
+	{version: 7, name: "entry headings", sql: schemaV7, reindex: true},
+	{version: 8, name: "code examples", sql: schemaV8, reindex: true},
+	{version: 9, name: "content digests", sql: schemaV9, reindex: true},
+	{version: 10, name: "spelling vocabulary by entry", sql: schemaV10},
+}
+
+// SchemaVersion returns the schema version this build migrates to
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate brings the database up to SchemaVersion, backing it up first
+func (db *DB) migrate() error {
+	current, err := db.userVersion()
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	latest := SchemaVersion()
+	if current > latest {
+		return fmt.Errorf("%w (database is at version %d, this build supports up to %d)", ErrSchemaTooNew, current, latest)
+	}
+	if current == latest {
+		return nil
+	}
+
+	if err := db.backup(current); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	vacuum := false
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := db.apply(m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+		vacuum = vacuum || m.vacuum
+	}
+
+	// VACUUM can't run inside a transaction, so do it once at the end
+	if vacuum {
+		if _, err := db.conn.Exec("VACUUM"); err != nil {
+			return fmt.Errorf("failed to vacuum database: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// apply runs a single migration and records its version
+func (db *DB) apply(m migration) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.sql); err != nil {
+		return err
+	}
+
+	if m.reindex {
+		if _, err := tx.Exec("UPDATE docsets SET needs_reindex = 1"); err != nil {
+			return fmt.Errorf("failed to flag docsets for reindex: %w", err)
+		}
+	}
+
+	// PRAGMA doesn't accept bound parameters
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", m.version)); err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// userVersion returns the schema version stored in the database header
+func (db *DB) userVersion() (int, error) {
+	var version int
+	err := db.conn.QueryRow("PRAGMA user_version").Scan(&version)
+	return version, err
+}
+
+// backup writes a consistent copy of the database next to it before
+// migrating. Empty (freshly created) databases are not backed up.
+func (db *DB) backup(version int) error {
+	var tables int
+	if err := db.conn.QueryRow("SELECT count(*) FROM sqlite_master").Scan(&tables); err != nil {
+		return err
+	}
+	if tables == 0 {
+		return nil
+	}
+
+	path := fmt.Sprintf("%s.v%d.bak", db.path, version)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	_, err := db.conn.Exec("VACUUM INTO ?", path)
+	return err
+}
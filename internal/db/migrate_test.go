@@ -0,0 +1,227 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+// baselineSchema is the schema written by builds from before schema
+// versioning, which left user_version at 0. It is copied here rather than
+// shared with schemaV1 so the fixture stays what real indexes look like.
+const baselineSchema = `
+CREATE VIRTUAL TABLE IF NOT EXISTS docs USING fts5(
+    docset,
+    version,
+    symbol,
+    title,
+    content,
+    path,
+    tokenize = 'porter unicode61'
+);
+
+CREATE TABLE IF NOT EXISTS docsets (
+    id INTEGER PRIMARY KEY,
+    slug TEXT UNIQUE NOT NULL,
+    name TEXT NOT NULL,
+    version TEXT NOT NULL DEFAULT '',
+    display_name TEXT,
+    entry_count INTEGER DEFAULT 0,
+    mtime INTEGER,
+    installed_at INTEGER DEFAULT (strftime('%s', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_docsets_name ON docsets(name);
+`
+
+// createLegacy writes a database as an older build left it, with the given
+// schema and user_version, and one docset holding two entries
+func createLegacy(t *testing.T, path, schema string, version int) {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for _, stmt := range []string{
+		schema,
+		`INSERT INTO docsets (slug, name, display_name, entry_count, mtime) VALUES ('js', 'js', 'JavaScript', 2, 1700000000)`,
+		`INSERT INTO docs (docset, version, symbol, title, content, path)
+			VALUES ('js', '', 'Array.prototype.map', 'map', '# map', 'array/map')`,
+		`INSERT INTO docs (docset, version, symbol, title, content, path)
+			VALUES ('js', '', 'Array.prototype.filter', 'filter', '# filter', 'array/filter')`,
+		fmt.Sprintf("PRAGMA user_version = %d", version),
+	} {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("creating v%d database: %v", version, err)
+		}
+	}
+}
+
+// createV1 writes a database as a version 1 build left it
+func createV1(t *testing.T, path string) {
+	createLegacy(t, path, schemaV1, 1)
+}
+
+func TestMigrateFromBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.sqlite")
+	createLegacy(t, path, baselineSchema, 0)
+
+	database, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer database.Close()
+
+	if v, _ := database.userVersion(); v != SchemaVersion() {
+		t.Errorf("user_version = %d; want %d", v, SchemaVersion())
+	}
+	if _, err := os.Stat(path + ".v0.bak"); err != nil {
+		t.Errorf("no backup of the baseline database: %v", err)
+	}
+
+	searcher := NewSearcher(database)
+	for _, want := range []model.Entry{
+		{Path: "array/map", Symbol: "Array.prototype.map", Content: "# map"},
+		{Path: "array/filter", Symbol: "Array.prototype.filter", Content: "# filter"},
+	} {
+		entry, err := searcher.GetEntry("js", "", want.Path)
+		if err != nil {
+			t.Errorf("entry %s lost in migration: %v", want.Path, err)
+			continue
+		}
+		if entry.Symbol != want.Symbol || entry.Content != want.Content {
+			t.Errorf("migrated entry = %+v; want %+v", entry, want)
+		}
+	}
+
+	// Content now lives in entries, with docs as an external content index
+	var entries int
+	if err := database.conn.QueryRow("SELECT count(*) FROM entries").Scan(&entries); err != nil {
+		t.Fatalf("counting entries: %v", err)
+	}
+	if entries != 2 {
+		t.Errorf("entries table holds %d rows; want 2", entries)
+	}
+	results, err := searcher.Search("filter", "js", "", 10, 0)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Entry.Path != "array/filter" {
+		t.Errorf("Search(filter) = %+v; want array/filter", results)
+	}
+
+	pending, err := NewIndexer(database).PendingReindex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Slug != "js" {
+		t.Errorf("PendingReindex() = %+v; want js flagged for a rebuild", pending)
+	}
+}
+
+func TestMigrateFromV1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.sqlite")
+	createV1(t, path)
+
+	database, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer database.Close()
+
+	if v, _ := database.userVersion(); v != SchemaVersion() {
+		t.Errorf("user_version = %d; want %d", v, SchemaVersion())
+	}
+	if _, err := os.Stat(path + ".v1.bak"); err != nil {
+		t.Errorf("no backup of the v1 database: %v", err)
+	}
+
+	entry, err := NewSearcher(database).GetEntry("js", "", "array/map")
+	if err != nil {
+		t.Fatalf("entry lost in migration: %v", err)
+	}
+	if entry.Symbol != "Array.prototype.map" || entry.Content != "# map" {
+		t.Errorf("migrated entry = %+v", entry)
+	}
+
+	pending, err := NewIndexer(database).PendingReindex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Slug != "js" {
+		t.Errorf("PendingReindex() = %+v; want js flagged for a rebuild", pending)
+	}
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.sqlite")
+	database, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	database.Close()
+
+	if _, err := os.Stat(path + ".v0.bak"); !os.IsNotExist(err) {
+		t.Errorf("an empty database was backed up")
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.sqlite")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec("PRAGMA user_version = 999"); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	if _, err := Open(path); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Open of a newer database = %v; want ErrSchemaTooNew", err)
+	}
+}
+
+func TestOpenReadOnlyLeavesOldSchema(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.sqlite")
+
+	if _, err := OpenReadOnly(path); err == nil {
+		t.Error("OpenReadOnly of a missing database succeeded")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("OpenReadOnly created the database")
+	}
+
+	createV1(t, path)
+	if _, err := OpenReadOnly(path); err == nil {
+		t.Error("OpenReadOnly of a version 1 database succeeded")
+	}
+	if _, err := os.Stat(path + ".v1.bak"); !os.IsNotExist(err) {
+		t.Error("OpenReadOnly backed up the database")
+	}
+
+	database, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	database.Close()
+
+	database, err = OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly after migrating: %v", err)
+	}
+	defer database.Close()
+	if _, err := NewSearcher(database).GetEntry("js", "", "array/map"); err != nil {
+		t.Errorf("GetEntry: %v", err)
+	}
+}
@@ -0,0 +1,391 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+// Query is a parsed search query. Free text becomes an FTS5 MATCH
+// expression; filters become SQL conditions.
+//
+// Syntax:
+//
+//	word            entries containing word (the last word is a prefix)
+//	"exact phrase"  entries containing the words in order
+//	-word           exclude entries containing word (also -"phrase")
+//	a OR b          either side matches; terms bind tighter than OR
+//	symbol:word     word must appear in the symbol
+//	docset:rails    only the rails docset (docset:rails~7.1 sets the version)
+//	version:7.1     only version 7.1
+//	type:method     only entries whose DevDocs type contains "method"
+type Query struct {
+	Docset  string
+	Version string
+	Type    string
+
+	// groups are OR-ed together; the terms in a group are AND-ed
+	groups [][]term
+}
+
+// term is a single word or phrase in a query
+type term struct {
+	text   string
+	negate bool
+	symbol bool // restricted to the symbol column
+	prefix bool // typeahead prefix match
+
+	// Rune offsets of the text in the input, for respelling it in place
+	start, end int
+}
+
+// QueryError describes malformed query input
+type QueryError struct {
+	Pos int // Rune offset of the problem in the input
+	Msg string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid query at column %d: %s", e.Pos+1, e.Msg)
+}
+
+// ParseQuery parses the search syntax described on Query
+func ParseQuery(input string) (*Query, error) {
+	p := &queryParser{input: []rune(input)}
+	return p.parse()
+}
+
+// queryParser is a small hand-written scanner for ParseQuery
+type queryParser struct {
+	input []rune
+	pos   int
+}
+
+func (p *queryParser) parse() (*Query, error) {
+	q := &Query{}
+	group := []term{}
+	orPos := -1         // Position of an OR still waiting for its right-hand side
+	lastIsWord := false // Whether the last token was a positive word
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) {
+			break
+		}
+
+		start := p.pos
+		lastIsWord = false
+		negate := false
+		if p.input[p.pos] == '-' && p.pos+1 < len(p.input) && !unicode.IsSpace(p.input[p.pos+1]) {
+			negate = true
+			p.pos++
+		}
+
+		if p.input[p.pos] == '"' {
+			quote := p.pos
+			text, err := p.readPhrase()
+			if err != nil {
+				return nil, err
+			}
+			group = append(group, term{text: text, negate: negate, start: quote + 1, end: p.pos - 1})
+			orPos = -1
+			continue
+		}
+
+		wordStart := p.pos
+		word := p.readWord()
+
+		if !negate && (word == "OR" || word == "AND") {
+			if word == "OR" {
+				if len(group) == 0 || orPos >= 0 {
+					return nil, &QueryError{Pos: start, Msg: "OR needs a term on both sides"}
+				}
+				q.groups = append(q.groups, group)
+				group = []term{}
+				orPos = start
+			}
+			continue
+		}
+
+		if field, value, ok := splitField(word); ok {
+			if field != "symbol" && negate {
+				return nil, &QueryError{Pos: start, Msg: fmt.Sprintf("%s: filters can't be excluded", field)}
+			}
+
+			// symbol:"a phrase"
+			if field == "symbol" && value == "" && p.pos < len(p.input) && p.input[p.pos] == '"' {
+				quote := p.pos
+				text, err := p.readPhrase()
+				if err != nil {
+					return nil, err
+				}
+				group = append(group, term{text: text, negate: negate, symbol: true, start: quote + 1, end: p.pos - 1})
+				orPos = -1
+				continue
+			}
+
+			if value == "" {
+				return nil, &QueryError{Pos: start, Msg: fmt.Sprintf("%s: needs a value", field)}
+			}
+
+			if field == "symbol" {
+				group = append(group, term{text: value, negate: negate, symbol: true, start: p.pos - len([]rune(value)), end: p.pos})
+				orPos = -1
+				lastIsWord = !negate
+				continue
+			}
+
+			if err := q.setFilter(field, value, start); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if !strings.ContainsFunc(word, isWordRune) {
+			// Punctuation only; nothing the tokenizers would index
+			continue
+		}
+
+		group = append(group, term{text: word, negate: negate, start: wordStart, end: p.pos})
+		orPos = -1
+		lastIsWord = !negate
+	}
+
+	if orPos >= 0 {
+		return nil, &QueryError{Pos: orPos, Msg: "OR needs a term on both sides"}
+	}
+
+	// The last word typed gets prefix matching for typeahead, unless the
+	// input ends in whitespace
+	if lastIsWord && !unicode.IsSpace(p.input[len(p.input)-1]) {
+		group[len(group)-1].prefix = true
+	}
+
+	if len(group) > 0 {
+		q.groups = append(q.groups, group)
+	}
+
+	for _, g := range q.groups {
+		if !hasPositive(g) {
+			return nil, &QueryError{Pos: 0, Msg: "excluded terms need at least one term to exclude them from"}
+		}
+	}
+
+	if len(q.groups) == 0 {
+		return nil, &QueryError{Pos: 0, Msg: "no search terms"}
+	}
+
+	return q, nil
+}
+
+// setFilter records a docset:, version: or type: filter
+func (q *Query) setFilter(field, value string, pos int) error {
+	var dst *string
+	switch field {
+	case "docset":
+		name, version := model.ParseSlug(value)
+		if version != "" {
+			if q.Version != "" {
+				return &QueryError{Pos: pos, Msg: "version given more than once"}
+			}
+			q.Version = version
+		}
+		value = name
+		dst = &q.Docset
+	case "version":
+		dst = &q.Version
+	case "type":
+		dst = &q.Type
+	}
+
+	if *dst != "" {
+		return &QueryError{Pos: pos, Msg: fmt.Sprintf("%s: given more than once", field)}
+	}
+	*dst = value
+	return nil
+}
+
+// skipSpace advances past whitespace
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// readWord reads up to the next whitespace or quote
+func (p *queryParser) readWord() string {
+	start := p.pos
+	for p.pos < len(p.input) && !unicode.IsSpace(p.input[p.pos]) && p.input[p.pos] != '"' {
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
+
+// readPhrase reads a double-quoted phrase starting at the opening quote
+func (p *queryParser) readPhrase() (string, error) {
+	start := p.pos
+	p.pos++ // opening quote
+	for p.pos < len(p.input) && p.input[p.pos] != '"' {
+		p.pos++
+	}
+	if p.pos >= len(p.input) {
+		return "", &QueryError{Pos: start, Msg: "unterminated quote"}
+	}
+
+	text := strings.TrimSpace(string(p.input[start+1 : p.pos]))
+	p.pos++ // closing quote
+	if !strings.ContainsFunc(text, isWordRune) {
+		return "", &QueryError{Pos: start, Msg: "empty phrase"}
+	}
+	return text, nil
+}
+
+// splitField recognizes "field:value" for the known fields. Anything else
+// containing a colon, such as "ActiveRecord::Base", is an ordinary word.
+func splitField(word string) (field, value string, ok bool) {
+	name, value, found := strings.Cut(word, ":")
+	if !found || strings.HasPrefix(value, ":") {
+		return "", "", false
+	}
+
+	switch name {
+	case "docset", "version", "type", "symbol":
+		return name, value, true
+	}
+	return "", "", false
+}
+
+// hasPositive reports whether a group has a term that isn't excluded
+func hasPositive(group []term) bool {
+	for _, t := range group {
+		if !t.negate {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchExpr compiles the query's text to an FTS5 MATCH expression for docs
+func (q *Query) MatchExpr() string {
+	var groups []string
+	for _, g := range q.groups {
+		groups = append(groups, compileGroup(g, func(t term) string {
+			expr := quoteFTS5(t.text)
+			if t.prefix {
+				expr += "*"
+			}
+			if t.symbol {
+				expr = "symbol : " + expr
+			}
+			return expr
+		}))
+	}
+	return joinGroups(groups)
+}
+
+// CodeMatchExpr compiles the query's text to an FTS5 MATCH expression for
+// the code examples index, which has no symbol column
+func (q *Query) CodeMatchExpr() string {
+	var groups []string
+	for _, g := range q.groups {
+		groups = append(groups, compileGroup(g, func(t term) string {
+			expr := quoteFTS5(t.text)
+			if t.prefix {
+				expr += "*"
+			}
+			return expr
+		}))
+	}
+	return joinGroups(groups)
+}
+
+// SymbolMatchExpr compiles the query to a MATCH expression for the trigram
+// symbols index, so fragments match anywhere inside a symbol. Fragments
+// shorter than three characters can't use a trigram index, so groups with
+// any of those are left to MatchExpr; matching the rest of such a group
+// alone would widen it. Queries with excluded terms return "", since an
+// exclusion must hold across every column, not just the symbol.
+func (q *Query) SymbolMatchExpr() string {
+	var groups []string
+	for _, g := range q.groups {
+		usable := true
+		for _, t := range g {
+			if t.negate {
+				return ""
+			}
+			if len([]rune(t.text)) < 3 {
+				usable = false
+			}
+		}
+		if !usable {
+			continue
+		}
+		groups = append(groups, compileGroup(g, func(t term) string {
+			return quoteFTS5(t.text)
+		}))
+	}
+	return joinGroups(groups)
+}
+
+// Text returns the positive words and phrases of the query as plain text,
+// used to boost symbols that match it
+func (q *Query) Text() string {
+	return strings.Join(q.Terms(), " ")
+}
+
+// Terms returns the positive words and phrases of the query
+func (q *Query) Terms() []string {
+	var terms []string
+	for _, g := range q.groups {
+		for _, t := range g {
+			if !t.negate {
+				terms = append(terms, t.text)
+			}
+		}
+	}
+	return terms
+}
+
+// compileGroup ANDs a group's positive terms and excludes its negative ones
+func compileGroup(group []term, compile func(term) string) string {
+	var positive, negative []string
+	for _, t := range group {
+		if t.negate {
+			negative = append(negative, compile(t))
+		} else {
+			positive = append(positive, compile(t))
+		}
+	}
+
+	expr := strings.Join(positive, " AND ")
+	if len(negative) > 0 {
+		if len(positive) > 1 {
+			expr = "(" + expr + ")"
+		}
+		expr += " NOT " + strings.Join(negative, " NOT ")
+	}
+	return expr
+}
+
+// joinGroups ORs compiled groups together
+func joinGroups(groups []string) string {
+	if len(groups) <= 1 {
+		return strings.Join(groups, "")
+	}
+	for i, g := range groups {
+		groups[i] = "(" + g + ")"
+	}
+	return strings.Join(groups, " OR ")
+}
+
+// quoteFTS5 wraps s in double quotes as an FTS5 string, escaping quotes
+func quoteFTS5(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// isWordRune reports whether r is part of a token for the FTS5 tokenizers
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
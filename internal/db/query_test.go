@@ -0,0 +1,64 @@
+package db
+
+import "testing"
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		input   string
+		match   string
+		docset  string
+		version string
+		typ     string
+	}{
+		{input: "map", match: `"map"*`},
+		{input: "array map", match: `"array" AND "map"*`},
+		{input: `"new array" map`, match: `"new array" AND "map"*`},
+		{input: "map -filter", match: `"map" NOT "filter"`},
+		{input: "map OR filter", match: `("map") OR ("filter"*)`},
+		{input: "symbol:map", match: `symbol : "map"*`},
+		{input: "docset:rails~7.1 where", match: `"where"*`, docset: "rails", version: "7.1"},
+		{input: "version:3.12 open", match: `"open"*`, version: "3.12"},
+		{input: "type:method each", match: `"each"*`, typ: "method"},
+	}
+
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.input)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", tt.input, err)
+			continue
+		}
+		if got := q.MatchExpr(); got != tt.match {
+			t.Errorf("ParseQuery(%q).MatchExpr() = %s; want %s", tt.input, got, tt.match)
+		}
+		if q.Docset != tt.docset || q.Version != tt.version || q.Type != tt.typ {
+			t.Errorf("ParseQuery(%q) filters = %q %q %q; want %q %q %q",
+				tt.input, q.Docset, q.Version, q.Type, tt.docset, tt.version, tt.typ)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{input: "OR map", pos: 0},
+		{input: "map OR", pos: 4},
+		{input: "map OR OR filter", pos: 7},
+		{input: `map "open`, pos: 4},
+		{input: "map -docset:rails", pos: 4},
+		{input: "-map", pos: 0},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseQuery(tt.input)
+		queryErr, ok := err.(*QueryError)
+		if !ok {
+			t.Errorf("ParseQuery(%q) error = %v; want a *QueryError", tt.input, err)
+			continue
+		}
+		if queryErr.Pos != tt.pos {
+			t.Errorf("ParseQuery(%q) error at %d (%v); want %d", tt.input, queryErr.Pos, err, tt.pos)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/config"
+)
+
+// RankWeights tunes how search results are ordered. BM25 scores are
+// negative (lower is better), so boosts are subtracted from them.
+type RankWeights struct {
+	Symbol  float64 // BM25 weight of the symbol column
+	Title   float64 // BM25 weight of the title column
+	Content float64 // BM25 weight of the content column
+
+	ExactBoost     float64 // Symbol, or its last segment, equals the query
+	PrefixBoost    float64 // Symbol starts with the query
+	WordBoost      float64 // Query starts at a word inside the symbol
+	SubstringBoost float64 // Query appears anywhere in the symbol
+}
+
+// DefaultRankWeights returns the weights of the default config
+func DefaultRankWeights() RankWeights {
+	return RankWeightsFromConfig(config.DefaultConfig().Search)
+}
+
+// RankWeightsFromConfig returns the weights set in the search config
+func RankWeightsFromConfig(c config.SearchConfig) RankWeights {
+	return RankWeights{
+		Symbol:         c.SymbolWeight,
+		Title:          c.TitleWeight,
+		Content:        c.ContentWeight,
+		ExactBoost:     c.ExactBoost,
+		PrefixBoost:    c.PrefixBoost,
+		WordBoost:      c.WordBoost,
+		SubstringBoost: c.SubstringBoost,
+	}
+}
+
+// bm25Expr scores a row of docs using the configured column weights
+const bm25Expr = `bm25(docs, :symbol_weight, :title_weight, :content_weight)`
+
+// boostExpr lifts symbol matches of entries e. A trailing "()" on the
+// symbol is ignored, so "map" exactly matches "Array.prototype.map()".
+const boostExpr = `CASE
+			WHEN rtrim(lower(e.symbol), '()') = :symbol_query
+				OR substr(rtrim(lower(e.symbol), '()'), -(length(:symbol_query) + 1))
+					IN ('.' || :symbol_query, ':' || :symbol_query, '#' || :symbol_query)
+				THEN :exact_boost
+			WHEN substr(lower(e.symbol), 1, length(:symbol_query)) = :symbol_query
+				THEN :prefix_boost
+			WHEN :symbol_words != '' AND instr(e.symbol_words, :symbol_words) > 0
+				THEN :word_boost
+			WHEN instr(lower(e.symbol), :symbol_query) > 0
+				THEN :substring_boost
+			ELSE 0
+		END`
+
+// rankArgs returns the named arguments used by bm25Expr and boostExpr,
+// given the plain text of the query
+func (w RankWeights) rankArgs(query string) []any {
+	return []any{
+		sql.Named("symbol_weight", w.Symbol),
+		sql.Named("title_weight", w.Title),
+		sql.Named("content_weight", w.Content),
+		sql.Named("symbol_query", symbolQuery(query)),
+		sql.Named("symbol_words", strings.TrimRight(symbolWords(query), " ")),
+		sql.Named("exact_boost", w.ExactBoost),
+		sql.Named("prefix_boost", w.PrefixBoost),
+		sql.Named("word_boost", w.WordBoost),
+		sql.Named("substring_boost", w.SubstringBoost),
+	}
+}
+
+// symbolQuery normalizes a raw query for comparison against symbols
+func symbolQuery(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
@@ -0,0 +1,107 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+// openFixture opens a fresh index in a temp dir holding the given entries,
+// all in one "js" docset
+func openFixture(t *testing.T, entries ...model.Entry) *DB {
+	t.Helper()
+	database, err := Open(filepath.Join(t.TempDir(), "index.sqlite"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	for i := range entries {
+		entries[i].Docset = "js"
+		if entries[i].Title == "" {
+			entries[i].Title = entries[i].Symbol
+		}
+	}
+	docset := model.Docset{Slug: "js", Name: "js", DisplayName: "JavaScript"}
+	if err := NewIndexer(database).IndexDocset(docset, entries); err != nil {
+		t.Fatalf("IndexDocset: %v", err)
+	}
+	return database
+}
+
+func entry(symbol, path, content string) model.Entry {
+	return model.Entry{Symbol: symbol, Path: path, Type: "Array", Content: content}
+}
+
+func searchSymbols(t *testing.T, database *DB, query string) []string {
+	t.Helper()
+	results, err := NewSearcher(database).Search(query, "", "", 50, 0)
+	if err != nil {
+		t.Fatalf("Search(%q): %v", query, err)
+	}
+	var symbols []string
+	for _, r := range results {
+		symbols = append(symbols, r.Symbol)
+	}
+	return symbols
+}
+
+func TestExactSymbolOutranksTutorial(t *testing.T) {
+	database := openFixture(t,
+		entry("Iterating collections", "guide/iterating", "# Iterating\n\n"+strings.Repeat("Use map to transform. ", 40)),
+		entry("Array.prototype.map", "array/map", "# map\n\nCreates a new array with the results of calling a function."),
+	)
+
+	got := searchSymbols(t, database, "map")
+	if len(got) != 2 || got[0] != "Array.prototype.map" {
+		t.Errorf("Search(map) = %q; want Array.prototype.map first", got)
+	}
+}
+
+func TestSymbolMatchTiers(t *testing.T) {
+	database := openFixture(t,
+		entry("Bitmapper", "bitmapper", "# Bitmapper\n\nDraws a map of bits."),
+		entry("Array.prototype.flatMap", "array/flatmap", "# flatMap\n\nMaps, then flattens one level: like map."),
+		entry("mapAsync", "mapasync", "# mapAsync\n\nAn async map."),
+		entry("Array.prototype.map", "array/map", "# map\n\nCreates a new array."),
+		entry("Iterating collections", "guide/iterating", "# Iterating\n\n"+strings.Repeat("Use map to transform. ", 40)),
+	)
+
+	want := []string{"Array.prototype.map", "mapAsync", "Array.prototype.flatMap", "Bitmapper", "Iterating collections"}
+	got := searchSymbols(t, database, "map")
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Search(map) ranked\n  %q\nwant exact > prefix > word > substring > body text\n  %q", got, want)
+	}
+}
+
+func TestDefaultBoostTiers(t *testing.T) {
+	w := DefaultRankWeights()
+	if w.ExactBoost <= w.PrefixBoost || w.PrefixBoost <= w.WordBoost || w.WordBoost <= w.SubstringBoost {
+		t.Errorf("default boosts %+v don't form exact > prefix > word > substring tiers", w)
+	}
+}
+
+func TestShortTermKeepsGroupOutOfSymbolMatch(t *testing.T) {
+	database := openFixture(t,
+		entry("String.prototype.toString", "string/tostring", "# toString\n\nConverts to a string."),
+		entry("String.prototype.trim", "string/trim", "# trim\n\nTrims a string."),
+	)
+
+	// "to" is too short for the trigram index; matching "string" there
+	// alone would return trim too
+	got := searchSymbols(t, database, "to string")
+	if len(got) != 1 || got[0] != "String.prototype.toString" {
+		t.Errorf("Search(to string) = %q; want only String.prototype.toString", got)
+	}
+	total, err := NewSearcher(database).CountResults("to string", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 {
+		t.Errorf("CountResults(to string) = %d; want 1", total)
+	}
+}
@@ -0,0 +1,250 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+const (
+	// relatedTerms is how many distinctive content words make up the
+	// query for Related
+	relatedTerms = 8
+
+	// Boosts for entries in the same symbol namespace and of the same type
+	relatedNamespaceBoost = 10
+	relatedTypeBoost      = 5
+)
+
+// stopWords are common English words that never make a page distinctive
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "that": true, "this": true,
+	"with": true, "are": true, "was": true, "not": true, "but": true,
+	"from": true, "can": true, "its": true, "has": true, "have": true,
+	"will": true, "you": true, "your": true, "all": true, "any": true,
+	"one": true, "which": true, "when": true, "use": true, "used": true,
+	"using": true, "also": true, "into": true, "than": true, "then": true,
+	"there": true, "their": true, "these": true, "those": true, "been": true,
+	"other": true, "such": true, "only": true, "may": true, "must": true,
+	"should": true, "would": true, "each": true, "more": true, "most": true,
+	"see": true, "example": true, "examples": true, "returns": true,
+	"return": true, "value": true, "values": true, "true": true, "false": true,
+	"null": true, "none": true, "new": true, "following": true,
+}
+
+// Related finds entries similar to entry: those sharing its most
+// distinctive content words or its symbol namespace, ranking entries of
+// the same type higher. Unless allDocsets is set, results come from the
+// entry's own docset.
+func (s *Searcher) Related(entry model.Entry, allDocsets bool, limit int) ([]model.SearchResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	// Lists don't carry content
+	content := entry.Content
+	if content == "" {
+		err := s.db.conn.QueryRow("SELECT content FROM entries WHERE id = ?", entry.ID).Scan(&content)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to load entry content: %w", err)
+		}
+	}
+
+	// Distinctive within the docset searched, not the whole index
+	scope := ""
+	var scopeArgs []any
+	if !allDocsets {
+		scope = " AND e.docset = :docset AND e.version = :version"
+		scopeArgs = append(scopeArgs, sql.Named("docset", entry.Docset), sql.Named("version", entry.Version))
+	}
+
+	terms, err := s.distinctiveTerms(content, relatedTerms, scope, scopeArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []string
+	for _, t := range terms {
+		parts = append(parts, quoteFTS5(t))
+	}
+
+	// Siblings in the namespace match even without shared content
+	namespace := symbolNamespace(entry.Symbol)
+	var nsWords []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(namespace), func(r rune) bool { return !isWordRune(r) }) {
+		nsWords = append(nsWords, "symbol : "+quoteFTS5(w))
+	}
+	if len(nsWords) > 0 {
+		parts = append(parts, "("+strings.Join(nsWords, " AND ")+")")
+	}
+
+	if len(parts) == 0 {
+		return nil, nil
+	}
+
+	args := []any{
+		sql.Named("query", strings.Join(parts, " OR ")),
+		sql.Named("id", entry.ID),
+		sql.Named("namespace", namespace),
+		sql.Named("type", entry.Type),
+		sql.Named("namespace_boost", relatedNamespaceBoost),
+		sql.Named("type_boost", relatedTypeBoost),
+		sql.Named("limit", limit),
+	}
+	args = append(args, s.weights.rankArgs("")...)
+	args = append(args, scopeArgs...)
+
+	stmt := fmt.Sprintf(`
+		WITH hits AS MATERIALIZED (
+			SELECT rowid AS id, %s AS score FROM docs WHERE docs MATCH :query
+		)
+		SELECT
+			e.id,
+			e.docset,
+			e.version,
+			e.symbol,
+			e.type,
+			e.title,
+			e.path,
+			h.score
+				- CASE WHEN :namespace != '' AND substr(e.symbol, 1, length(:namespace)) = :namespace
+					THEN :namespace_boost ELSE 0 END
+				- CASE WHEN :type != '' AND e.type = :type
+					THEN :type_boost ELSE 0 END AS rank
+		FROM hits h
+		JOIN entries e ON e.id = h.id
+		WHERE e.id != :id%s
+		ORDER BY rank, e.id
+		LIMIT :limit
+	`, bm25Expr, scope)
+
+	rows, err := s.db.conn.Query(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("related query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var results []model.SearchResult
+	for rows.Next() {
+		var r model.SearchResult
+		err := rows.Scan(&r.ID, &r.Docset, &r.Version, &r.Symbol, &r.Type, &r.Title, &r.Path, &r.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan related entry: %w", err)
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+// distinctiveTerms returns up to n words of content that are frequent in
+// it but rare among the entries matching scope, a condition on entries e,
+// by TF-IDF
+func (s *Searcher) distinctiveTerms(content string, n int, scope string, scopeArgs []any) ([]string, error) {
+	counts := make(map[string]int)
+	for _, w := range strings.FieldsFunc(strings.ToLower(content), func(r rune) bool { return !isWordRune(r) }) {
+		if len([]rune(w)) < 3 || isNumber(w) || stopWords[w] {
+			continue
+		}
+		counts[w]++
+	}
+	if len(counts) == 0 {
+		return nil, nil
+	}
+
+	// Only the most frequent words are worth a document frequency lookup
+	words := make([]string, 0, len(counts))
+	for w := range counts {
+		words = append(words, w)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	if len(words) > n*4 {
+		words = words[:n*4]
+	}
+
+	// Document frequencies of every word in one statement. Each word is
+	// matched through docs rather than looked up in a vocabulary, so it is
+	// stemmed the way the index was.
+	values := make([]string, len(words))
+	args := append([]any{}, scopeArgs...)
+	for i, w := range words {
+		values[i] = fmt.Sprintf("(%d, :term%d)", i, i)
+		args = append(args, sql.Named(fmt.Sprintf("term%d", i), "content : "+quoteFTS5(w)))
+	}
+	stmt := fmt.Sprintf(`
+		WITH terms(i, expr) AS (VALUES %s)
+		SELECT
+			-1,
+			(SELECT count(*) FROM entries e WHERE 1 = 1%s)
+		UNION ALL
+		SELECT
+			t.i,
+			(SELECT count(*) FROM docs JOIN entries e ON e.id = docs.rowid
+				WHERE docs MATCH t.expr%s)
+		FROM terms t
+	`, strings.Join(values, ", "), scope, scope)
+
+	rows, err := s.db.conn.Query(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("document frequency query failed: %w", err)
+	}
+	defer rows.Close()
+
+	total := 0
+	dfs := make([]int, len(words))
+	for rows.Next() {
+		var i, df int
+		if err := rows.Scan(&i, &df); err != nil {
+			return nil, fmt.Errorf("failed to scan document frequency: %w", err)
+		}
+		if i < 0 {
+			total = df
+		} else {
+			dfs[i] = df
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float64, len(words))
+	for i, w := range words {
+		scores[w] = float64(counts[w]) * math.Log(float64(total+1)/float64(dfs[i]+1))
+	}
+
+	sort.SliceStable(words, func(i, j int) bool {
+		return scores[words[i]] > scores[words[j]]
+	})
+	if len(words) > n {
+		words = words[:n]
+	}
+
+	// Words in every entry say nothing about this one
+	terms := words[:0]
+	for _, w := range words {
+		if scores[w] > 0 {
+			terms = append(terms, w)
+		}
+	}
+	return terms, nil
+}
+
+// symbolNamespace returns the symbol up to its last separator, such as
+// "Array.prototype" for "Array.prototype.map" or "ActiveRecord::Base" for
+// "ActiveRecord::Base#save"
+func symbolNamespace(symbol string) string {
+	end := strings.LastIndexAny(symbol, ".#:/")
+	if end <= 0 {
+		return ""
+	}
+	return strings.TrimRight(symbol[:end], ".#:/")
+}
@@ -0,0 +1,53 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"testing"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+func TestRelated(t *testing.T) {
+	database := openFixture(t,
+		entry("Array.prototype.map", "array/map", "# map\n\nCalls a callback on every element and collects the results."),
+		entry("Array.prototype.filter", "array/filter", "# filter\n\nCalls a callback predicate on every element, keeping some."),
+		entry("Date.parse", "date/parse", "# parse\n\nParses a date string into a timestamp."),
+		entry("Date.now", "date/now", "# now\n\nThe current timestamp."),
+	)
+	py := []model.Entry{{Docset: "py", Symbol: "map", Title: "map", Path: "functions#map", Content: "Calls a callback on every element."}}
+	if err := NewIndexer(database).IndexDocset(model.Docset{Slug: "py", Name: "py"}, py); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSearcher(database)
+	mapEntry, err := s.GetEntry("js", "", "array/map")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := s.Related(*mapEntry, false, 10)
+	if err != nil {
+		t.Fatalf("Related: %v", err)
+	}
+	if len(results) == 0 || results[0].Symbol != "Array.prototype.filter" {
+		t.Errorf("Related(map) = %+v; want Array.prototype.filter first", results)
+	}
+	for _, r := range results {
+		if r.Docset != "js" || r.ID == mapEntry.ID {
+			t.Errorf("Related(map) returned %s from %s; want other js entries only", r.Symbol, r.Docset)
+		}
+	}
+
+	results, err = s.Related(*mapEntry, true, 10)
+	if err != nil {
+		t.Fatalf("Related across docsets: %v", err)
+	}
+	found := false
+	for _, r := range results {
+		found = found || r.Docset == "py"
+	}
+	if !found {
+		t.Errorf("Related(map) across docsets = %+v; want py's map too", results)
+	}
+}
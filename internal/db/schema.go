@@ -1,6 +1,9 @@
 package db
 
-const schema = `
+// schemaV1 is the original schema, before versioning was introduced.
+// It uses IF NOT EXISTS so databases created by older builds, which never
+// set user_version, migrate cleanly.
+const schemaV1 = `
 -- Main FTS5 table for full-text search
 CREATE VIRTUAL TABLE IF NOT EXISTS docs USING fts5(
     docset,
@@ -27,3 +30,228 @@ CREATE TABLE IF NOT EXISTS docsets (
 -- Index for faster docset lookups
 CREATE INDEX IF NOT EXISTS idx_docsets_name ON docsets(name);
 `
+
+// schemaV2 lets migrations flag docsets whose index must be rebuilt
+// from the raw files on disk
+const schemaV2 = `
+ALTER TABLE docsets ADD COLUMN needs_reindex INTEGER NOT NULL DEFAULT 0;
+`
+
+// schemaV3 moves entry content out of the FTS table into a regular entries
+// table, which the index references as external content. Listing entries
+// no longer reads content, and content is stored once instead of twice.
+const schemaV3 = `
+CREATE TABLE entries (
+    id INTEGER PRIMARY KEY,
+    docset TEXT NOT NULL,
+    version TEXT NOT NULL DEFAULT '',
+    symbol TEXT NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    content TEXT NOT NULL DEFAULT '',
+    path TEXT NOT NULL
+);
+
+CREATE INDEX idx_entries_symbol ON entries(docset, version, symbol);
+CREATE INDEX idx_entries_path ON entries(docset, version, path);
+
+INSERT INTO entries (docset, version, symbol, title, content, path)
+SELECT docset, version, symbol, title, content, path FROM docs;
+
+DROP TABLE docs;
+
+CREATE VIRTUAL TABLE docs USING fts5(
+    symbol,
+    title,
+    content,
+    content = 'entries',
+    content_rowid = 'id',
+    tokenize = 'porter unicode61'
+);
+
+INSERT INTO docs(docs) VALUES ('rebuild');
+
+-- Keep the index in sync with entries
+CREATE TRIGGER entries_ai AFTER INSERT ON entries BEGIN
+    INSERT INTO docs(rowid, symbol, title, content)
+    VALUES (new.id, new.symbol, new.title, new.content);
+END;
+
+CREATE TRIGGER entries_ad AFTER DELETE ON entries BEGIN
+    INSERT INTO docs(docs, rowid, symbol, title, content)
+    VALUES ('delete', old.id, old.symbol, old.title, old.content);
+END;
+
+CREATE TRIGGER entries_au AFTER UPDATE ON entries BEGIN
+    INSERT INTO docs(docs, rowid, symbol, title, content)
+    VALUES ('delete', old.id, old.symbol, old.title, old.content);
+    INSERT INTO docs(rowid, symbol, title, content)
+    VALUES (new.id, new.symbol, new.title, new.content);
+END;
+`
+
+// schemaV4 adds a trigram index over symbols so any fragment of a
+// qualified name finds it, plus the symbol split into lowercase words
+// (camelCase, snake_case, ".", "::", "#") for word-boundary ranking.
+// symbol_words is computed in Go, so existing docsets are reindexed.
+const schemaV4 = `
+ALTER TABLE entries ADD COLUMN symbol_words TEXT NOT NULL DEFAULT '';
+
+CREATE VIRTUAL TABLE symbols USING fts5(
+    symbol,
+    content = 'entries',
+    content_rowid = 'id',
+    tokenize = 'trigram'
+);
+
+INSERT INTO symbols(symbols) VALUES ('rebuild');
+
+DROP TRIGGER entries_ai;
+DROP TRIGGER entries_ad;
+DROP TRIGGER entries_au;
+
+CREATE TRIGGER entries_ai AFTER INSERT ON entries BEGIN
+    INSERT INTO docs(rowid, symbol, title, content)
+    VALUES (new.id, new.symbol, new.title, new.content);
+    INSERT INTO symbols(rowid, symbol) VALUES (new.id, new.symbol);
+END;
+
+CREATE TRIGGER entries_ad AFTER DELETE ON entries BEGIN
+    INSERT INTO docs(docs, rowid, symbol, title, content)
+    VALUES ('delete', old.id, old.symbol, old.title, old.content);
+    INSERT INTO symbols(symbols, rowid, symbol) VALUES ('delete', old.id, old.symbol);
+END;
+
+CREATE TRIGGER entries_au AFTER UPDATE ON entries BEGIN
+    INSERT INTO docs(docs, rowid, symbol, title, content)
+    VALUES ('delete', old.id, old.symbol, old.title, old.content);
+    INSERT INTO docs(rowid, symbol, title, content)
+    VALUES (new.id, new.symbol, new.title, new.content);
+    INSERT INTO symbols(symbols, rowid, symbol) VALUES ('delete', old.id, old.symbol);
+    INSERT INTO symbols(rowid, symbol) VALUES (new.id, new.symbol);
+END;
+`
+
+// schemaV5 records each entry's DevDocs type for type: filters. Types come
+// from the raw index, so existing docsets are reindexed.
+const schemaV5 = `
+ALTER TABLE entries ADD COLUMN type TEXT NOT NULL DEFAULT '';
+`
+
+// schemaV6 adds an unstemmed index over symbols and titles, whose
+// vocabulary feeds spelling suggestions. The porter-stemmed docs index
+// can't be used for that, since its terms aren't real words.
+const schemaV6 = `
+CREATE VIRTUAL TABLE words USING fts5(
+    symbol,
+    title,
+    content = 'entries',
+    content_rowid = 'id',
+    tokenize = 'unicode61'
+);
+
+CREATE VIRTUAL TABLE words_vocab USING fts5vocab(words, row);
+
+INSERT INTO words(words) VALUES ('rebuild');
+
+DROP TRIGGER entries_ai;
+DROP TRIGGER entries_ad;
+DROP TRIGGER entries_au;
+
+CREATE TRIGGER entries_ai AFTER INSERT ON entries BEGIN
+    INSERT INTO docs(rowid, symbol, title, content)
+    VALUES (new.id, new.symbol, new.title, new.content);
+    INSERT INTO symbols(rowid, symbol) VALUES (new.id, new.symbol);
+    INSERT INTO words(rowid, symbol, title) VALUES (new.id, new.symbol, new.title);
+END;
+
+CREATE TRIGGER entries_ad AFTER DELETE ON entries BEGIN
+    INSERT INTO docs(docs, rowid, symbol, title, content)
+    VALUES ('delete', old.id, old.symbol, old.title, old.content);
+    INSERT INTO symbols(symbols, rowid, symbol) VALUES ('delete', old.id, old.symbol);
+    INSERT INTO words(words, rowid, symbol, title) VALUES ('delete', old.id, old.symbol, old.title);
+END;
+
+CREATE TRIGGER entries_au AFTER UPDATE ON entries BEGIN
+    INSERT INTO docs(docs, rowid, symbol, title, content)
+    VALUES ('delete', old.id, old.symbol, old.title, old.content);
+    INSERT INTO docs(rowid, symbol, title, content)
+    VALUES (new.id, new.symbol, new.title, new.content);
+    INSERT INTO symbols(symbols, rowid, symbol) VALUES ('delete', old.id, old.symbol);
+    INSERT INTO symbols(rowid, symbol) VALUES (new.id, new.symbol);
+    INSERT INTO words(words, rowid, symbol, title) VALUES ('delete', old.id, old.symbol, old.title);
+    INSERT INTO words(rowid, symbol, title) VALUES (new.id, new.symbol, new.title);
+END;
+`
+
+// schemaV7 stores the headings of each entry for the outline view.
+// Headings are extracted in Go, so existing docsets are reindexed.
+const schemaV7 = `
+CREATE TABLE headings (
+    entry_id INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    level INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    PRIMARY KEY (entry_id, position)
+);
+`
+
+// schemaV8 indexes fenced code blocks on their own, so code examples can
+// be searched apart from prose. Blocks are extracted in Go, so existing
+// docsets are reindexed.
+const schemaV8 = `
+CREATE TABLE examples (
+    id INTEGER PRIMARY KEY,
+    entry_id INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    language TEXT NOT NULL,
+    code TEXT NOT NULL
+);
+
+CREATE INDEX idx_examples_entry ON examples(entry_id);
+
+CREATE VIRTUAL TABLE examples_fts USING fts5(
+    code,
+    content = 'examples',
+    content_rowid = 'id',
+    tokenize = 'unicode61'
+);
+
+CREATE TRIGGER examples_ai AFTER INSERT ON examples BEGIN
+    INSERT INTO examples_fts(rowid, code) VALUES (new.id, new.code);
+END;
+
+CREATE TRIGGER examples_ad AFTER DELETE ON examples BEGIN
+    INSERT INTO examples_fts(examples_fts, rowid, code) VALUES ('delete', old.id, old.code);
+END;
+
+CREATE TRIGGER examples_au AFTER UPDATE ON examples BEGIN
+    INSERT INTO examples_fts(examples_fts, rowid, code) VALUES ('delete', old.id, old.code);
+    INSERT INTO examples_fts(rowid, code) VALUES (new.id, new.code);
+END;
+`
+
+// schemaV9 fingerprints each entry's content so versions can be compared
+// symbol by symbol, and keeps the fingerprints of a docset's previous
+// install so an update can be compared with what it replaced. Digests are
+// computed in Go, so existing docsets are reindexed.
+const schemaV9 = `
+ALTER TABLE entries ADD COLUMN digest TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE snapshots (
+    docset TEXT NOT NULL,
+    version TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    type TEXT NOT NULL,
+    path TEXT NOT NULL,
+    digest TEXT NOT NULL,
+    taken_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+);
+
+CREATE INDEX idx_snapshots_docset ON snapshots(docset, version);
+`
+
+// schemaV10 lists where each word of the spelling vocabulary occurs, so
+// suggestions can keep to the docsets being searched
+const schemaV10 = `
+CREATE VIRTUAL TABLE words_instance USING fts5vocab(words, instance);
+`
@@ -1,69 +1,167 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/lazydocs/lazydocs/internal/model"
 )
 
 // Searcher handles FTS5 search queries
 type Searcher struct {
-	db *DB
+	db      *DB
+	weights RankWeights
+	scope   []model.Docset // Docsets searched when none is given; nil for all
 }
 
 // NewSearcher creates a new Searcher
 func NewSearcher(db *DB) *Searcher {
-	return &Searcher{db: db}
+	return &Searcher{db: db, weights: DefaultRankWeights()}
 }
 
-// Search performs a full-text search across all docsets or a specific one
-func (s *Searcher) Search(query string, docset string, version string, limit int) ([]model.SearchResult, error) {
-	if query == "" {
-		return nil, nil
+// SetRankWeights changes how search results are ranked
+func (s *Searcher) SetRankWeights(w RankWeights) {
+	s.weights = w
+}
+
+// SetScope limits searches that don't name a docset to the given ones.
+// Empty or nil lifts the limit.
+func (s *Searcher) SetScope(docsets []model.Docset) {
+	s.scope = docsets
+}
+
+// searchPlan is a compiled query: the hit sources and SQL filters shared
+// by Search and CountResults
+type searchPlan struct {
+	query      *Query
+	symbolHits string // UNION ALL clause for the trigram symbol index
+	where      string // Extra conditions on entries e
+	args       []any
+}
+
+// planSearch parses a query and compiles it against the docset scope.
+// docset: and version: filters in the query override the arguments.
+func (s *Searcher) planSearch(query, docset, version string) (*searchPlan, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
 	}
 
-	if limit <= 0 {
-		limit = 50
+	where, args := q.filters(docset, version, s.scope)
+	plan := &searchPlan{
+		query: q,
+		where: where,
+		args:  append(args, sql.Named("query", q.MatchExpr())),
 	}
 
-	// Build the FTS5 query
-	// Escape special FTS5 characters and add prefix matching
-	ftsQuery := escapeFTS5Query(query)
+	// Symbol fragments also match anywhere inside a symbol via the
+	// trigram index, even where the tokenizer can't split the word
+	if symbolMatch := q.SymbolMatchExpr(); symbolMatch != "" {
+		plan.symbolHits = `
+				UNION ALL
+				SELECT rowid, 0 FROM symbols WHERE symbols MATCH :symbol_match`
+		plan.args = append(plan.args, sql.Named("symbol_match", symbolMatch))
+	}
 
-	var args []any
-	var whereClause string
+	return plan, nil
+}
 
+// filters compiles the query's filters and the docset scope to conditions
+// on entries e. docset: and version: filters override the arguments, and
+// either overrides the searcher's scope.
+func (q *Query) filters(docset, version string, scope []model.Docset) (string, []any) {
+	if q.Docset != "" {
+		docset, version = q.Docset, q.Version
+	} else if q.Version != "" {
+		version = q.Version
+	}
+
+	var where string
+	var args []any
 	if docset != "" {
-		if version != "" {
-			whereClause = "AND docset = ? AND version = ?"
-			args = append(args, ftsQuery, docset, version, limit)
-		} else {
-			whereClause = "AND docset = ?"
-			args = append(args, ftsQuery, docset, limit)
+		where += " AND e.docset = :docset"
+		args = append(args, sql.Named("docset", docset))
+	} else if len(scope) > 0 {
+		pairs := make([]string, len(scope))
+		for i, ds := range scope {
+			pairs[i] = fmt.Sprintf("(:scope_docset%d, :scope_version%d)", i, i)
+			args = append(args,
+				sql.Named(fmt.Sprintf("scope_docset%d", i), ds.Name),
+				sql.Named(fmt.Sprintf("scope_version%d", i), ds.Version))
 		}
-	} else {
-		args = append(args, ftsQuery, limit)
+		where += " AND (e.docset, e.version) IN (VALUES " + strings.Join(pairs, ", ") + ")"
+	}
+	if version != "" {
+		where += " AND e.version = :version"
+		args = append(args, sql.Named("version", version))
+	}
+	if q.Type != "" {
+		where += " AND instr(lower(e.type), lower(:type)) > 0"
+		args = append(args, sql.Named("type", q.Type))
+	}
+	return where, args
+}
+
+// Search performs a full-text search across all docsets or a specific one,
+// returning up to limit results starting at offset. The query uses the
+// syntax described on Query; docset: and version: filters in it override
+// the docset and version arguments. Malformed queries return a *QueryError.
+func (s *Searcher) Search(query string, docset string, version string, limit, offset int) ([]model.SearchResult, error) {
+	if query == "" {
+		return nil, nil
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	plan, err := s.planSearch(query, docset, version)
+	if err != nil {
+		return nil, err
 	}
 
-	sql := fmt.Sprintf(`
+	args := append(plan.args, sql.Named("limit", limit), sql.Named("offset", offset))
+	args = append(args, s.weights.rankArgs(plan.query.Text())...)
+
+	// Snippets are only built for the rows that make the page
+	stmt := fmt.Sprintf(`
+		WITH text_hits AS MATERIALIZED (
+			SELECT rowid AS id, %s AS score FROM docs WHERE docs MATCH :query
+		),
+		hits AS (
+			SELECT id, min(score) AS score FROM (
+				SELECT id, score FROM text_hits%s
+			) GROUP BY id
+		)
 		SELECT
-			docset,
-			version,
-			symbol,
-			title,
-			content,
-			path,
-			bm25(docs) as rank,
-			snippet(docs, 4, '<mark>', '</mark>', '...', 32) as snippet
-		FROM docs
-		WHERE docs MATCH ?
-		%s
-		ORDER BY rank
-		LIMIT ?
-	`, whereClause)
+			r.*,
+			coalesce((
+				SELECT snippet(docs, 2, '<mark>', '</mark>', '...', 32)
+				FROM docs WHERE docs MATCH :query AND rowid = r.id
+			), '') AS snippet
+		FROM (
+			SELECT
+				e.id,
+				e.docset,
+				e.version,
+				e.symbol,
+				e.type,
+				e.title,
+				e.path,
+				h.score - %s AS rank
+			FROM hits h
+			JOIN entries e ON e.id = h.id
+			WHERE 1 = 1
+			%s
+			ORDER BY rank, e.id
+			LIMIT :limit OFFSET :offset
+		) r
+		ORDER BY r.rank, r.id
+	`, bm25Expr, plan.symbolHits, boostExpr, plan.where)
 
-	rows, err := s.db.conn.Query(sql, args...)
+	rows, err := s.db.conn.Query(stmt, args...)
 	if err != nil {
 		return nil, fmt.Errorf("search query failed: %w", err)
 	}
@@ -73,11 +171,12 @@ func (s *Searcher) Search(query string, docset string, version string, limit int
 	for rows.Next() {
 		var r model.SearchResult
 		err := rows.Scan(
+			&r.ID,
 			&r.Docset,
 			&r.Version,
 			&r.Symbol,
+			&r.Type,
 			&r.Title,
-			&r.Content,
 			&r.Path,
 			&r.Rank,
 			&r.Snippet,
@@ -91,38 +190,66 @@ func (s *Searcher) Search(query string, docset string, version string, limit int
 	return results, rows.Err()
 }
 
-// ListEntries returns all entries for a docset (for browsing without search)
-func (s *Searcher) ListEntries(docset string, version string, limit int) ([]model.Entry, error) {
+// CountResults returns the total number of matches for a search, taking the
+// same arguments as Search
+func (s *Searcher) CountResults(query string, docset string, version string) (int, error) {
+	if query == "" {
+		return 0, nil
+	}
+
+	plan, err := s.planSearch(query, docset, version)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := fmt.Sprintf(`
+		WITH hits AS (
+			SELECT rowid AS id, 0 FROM docs WHERE docs MATCH :query%s
+		)
+		SELECT count(DISTINCT e.id)
+		FROM hits h
+		JOIN entries e ON e.id = h.id
+		WHERE 1 = 1
+		%s
+	`, plan.symbolHits, plan.where)
+
+	var count int
+	if err := s.db.conn.QueryRow(stmt, plan.args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count query failed: %w", err)
+	}
+	return count, nil
+}
+
+// ListEntries returns entries for a docset in symbol order (for browsing
+// without search), up to limit entries after the given one. Pass nil for
+// the first page. Content is not loaded; use GetEntry for that.
+func (s *Searcher) ListEntries(docset string, version string, after *model.Entry, limit int) ([]model.Entry, error) {
 	if limit <= 0 {
 		limit = 100
 	}
 
-	var rows interface {
-		Next() bool
-		Scan(dest ...any) error
-		Err() error
-		Close() error
+	where := "docset = ?"
+	args := []any{docset}
+	if version != "" {
+		where += " AND version = ?"
+		args = append(args, version)
 	}
-	var err error
 
-	if version != "" {
-		rows, err = s.db.conn.Query(`
-			SELECT docset, version, symbol, title, content, path
-			FROM docs
-			WHERE docset = ? AND version = ?
-			ORDER BY symbol
-			LIMIT ?
-		`, docset, version, limit)
-	} else {
-		rows, err = s.db.conn.Query(`
-			SELECT docset, version, symbol, title, content, path
-			FROM docs
-			WHERE docset = ?
-			ORDER BY symbol
-			LIMIT ?
-		`, docset, limit)
+	// Keyset pagination on (symbol, id) stays fast deep into large docsets
+	if after != nil {
+		where += " AND (symbol, id) > (?, ?)"
+		args = append(args, after.Symbol, after.ID)
 	}
 
+	args = append(args, limit)
+
+	rows, err := s.db.conn.Query(`
+		SELECT id, docset, version, symbol, type, title, path
+		FROM entries
+		WHERE `+where+`
+		ORDER BY symbol, id
+		LIMIT ?
+	`, args...)
 	if err != nil {
 		return nil, fmt.Errorf("list query failed: %w", err)
 	}
@@ -131,7 +258,109 @@ func (s *Searcher) ListEntries(docset string, version string, limit int) ([]mode
 	var entries []model.Entry
 	for rows.Next() {
 		var e model.Entry
-		err := rows.Scan(&e.Docset, &e.Version, &e.Symbol, &e.Title, &e.Content, &e.Path)
+		err := rows.Scan(&e.ID, &e.Docset, &e.Version, &e.Symbol, &e.Type, &e.Title, &e.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// CountEntries returns the number of entries in a docset
+func (s *Searcher) CountEntries(docset string, version string) (int, error) {
+	where := "docset = ?"
+	args := []any{docset}
+	if version != "" {
+		where += " AND version = ?"
+		args = append(args, version)
+	}
+
+	var count int
+	err := s.db.conn.QueryRow("SELECT count(*) FROM entries WHERE "+where, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count query failed: %w", err)
+	}
+	return count, nil
+}
+
+// ListSymbols returns every entry of a docset without content, for
+// matchers that keep symbols in memory
+func (s *Searcher) ListSymbols(docset string, version string) ([]model.Entry, error) {
+	rows, err := s.db.conn.Query(`
+		SELECT id, docset, version, symbol, type, title, path
+		FROM entries
+		WHERE docset = ? AND version = ?
+		ORDER BY symbol
+	`, docset, version)
+	if err != nil {
+		return nil, fmt.Errorf("symbol query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.Entry
+	for rows.Next() {
+		var e model.Entry
+		err := rows.Scan(&e.ID, &e.Docset, &e.Version, &e.Symbol, &e.Type, &e.Title, &e.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// CompleteSymbols returns up to limit distinct symbols of a docset that
+// start with prefix, case-sensitively, for shell completion
+func (s *Searcher) CompleteSymbols(docset, version, prefix string, limit int) ([]string, error) {
+	// A range scan on the symbol index; U+10FFFF sorts after any
+	// continuation of the prefix
+	rows, err := s.db.conn.Query(`
+		SELECT DISTINCT symbol
+		FROM entries
+		WHERE docset = ? AND version = ? AND symbol >= ? AND symbol < ?
+		ORDER BY symbol
+		LIMIT ?
+	`, docset, version, prefix, prefix+"\U0010FFFF", limit)
+	if err != nil {
+		return nil, fmt.Errorf("symbol completion failed: %w", err)
+	}
+	defer rows.Close()
+
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol: %w", err)
+		}
+		symbols = append(symbols, symbol)
+	}
+
+	return symbols, rows.Err()
+}
+
+// FindEntries returns the entries of a docset whose path equals ref or
+// whose symbol equals it ignoring case. An exact path match comes first,
+// then exact symbol matches. Content is not loaded.
+func (s *Searcher) FindEntries(docset, version, ref string) ([]model.Entry, error) {
+	rows, err := s.db.conn.Query(`
+		SELECT id, docset, version, symbol, type, title, path
+		FROM entries
+		WHERE docset = ? AND version = ?
+			AND (path = ? OR symbol = ? COLLATE NOCASE)
+		ORDER BY path = ? DESC, symbol = ? DESC, symbol, id
+	`, docset, version, ref, ref, ref, ref)
+	if err != nil {
+		return nil, fmt.Errorf("entry lookup failed: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.Entry
+	for rows.Next() {
+		var e model.Entry
+		err := rows.Scan(&e.ID, &e.Docset, &e.Version, &e.Symbol, &e.Type, &e.Title, &e.Path)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan entry: %w", err)
 		}
@@ -145,10 +374,10 @@ func (s *Searcher) ListEntries(docset string, version string, limit int) ([]mode
 func (s *Searcher) GetEntry(docset, version, path string) (*model.Entry, error) {
 	var e model.Entry
 	err := s.db.conn.QueryRow(`
-		SELECT docset, version, symbol, title, content, path
-		FROM docs
+		SELECT id, docset, version, symbol, type, title, content, path
+		FROM entries
 		WHERE docset = ? AND version = ? AND path = ?
-	`, docset, version, path).Scan(&e.Docset, &e.Version, &e.Symbol, &e.Title, &e.Content, &e.Path)
+	`, docset, version, path).Scan(&e.ID, &e.Docset, &e.Version, &e.Symbol, &e.Type, &e.Title, &e.Content, &e.Path)
 
 	if err != nil {
 		return nil, err
@@ -176,34 +405,9 @@ func (s *Searcher) ListDocsets() ([]model.Docset, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan docset: %w", err)
 		}
+		d.InstalledAt = time.Unix(installedAt, 0)
 		docsets = append(docsets, d)
 	}
 
 	return docsets, rows.Err()
 }
-
-// escapeFTS5Query escapes special FTS5 characters and prepares the query
-func escapeFTS5Query(query string) string {
-	// Remove characters that have special meaning in FTS5
-	query = strings.ReplaceAll(query, "\"", "")
-	query = strings.ReplaceAll(query, "'", "")
-	query = strings.ReplaceAll(query, "(", "")
-	query = strings.ReplaceAll(query, ")", "")
-	query = strings.ReplaceAll(query, "*", "")
-	query = strings.ReplaceAll(query, ":", "")
-
-	// Split into words and add prefix matching
-	words := strings.Fields(query)
-	if len(words) == 0 {
-		return ""
-	}
-
-	// Add prefix matching to the last word for typeahead
-	for i := range words {
-		if i == len(words)-1 {
-			words[i] = words[i] + "*"
-		}
-	}
-
-	return strings.Join(words, " ")
-}
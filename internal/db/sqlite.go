@@ -9,6 +9,10 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// busyTimeout is how many milliseconds a connection waits for another
+// process's lock before giving up
+const busyTimeout = 5000
+
 // DB wraps the SQLite database connection
 type DB struct {
 	conn *sql.DB
@@ -23,8 +27,9 @@ func Open(path string) (*DB, error) {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
-	// Open with FTS5 support
-	conn, err := sql.Open("sqlite3", path+"?_fk=on")
+	// WAL lets searches read while an install writes; writers wait up to
+	// busyTimeout for each other
+	conn, err := sql.Open("sqlite3", fmt.Sprintf("%s?_fk=on&_journal_mode=WAL&_busy_timeout=%d", path, busyTimeout))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -40,10 +45,37 @@ func Open(path string) (*DB, error) {
 		path: path,
 	}
 
-	// Initialize schema
-	if err := db.initSchema(); err != nil {
+	// Create or upgrade the schema
+	if err := db.migrate(); err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	return db, nil
+}
+
+// OpenReadOnly opens an existing database for lookups that must be quick,
+// such as shell completion. It is neither created nor migrated, so a
+// database at another schema version is refused.
+func OpenReadOnly(path string) (*DB, error) {
+	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro&_fk=on&_busy_timeout=%d", path, busyTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db := &DB{
+		conn: conn,
+		path: path,
+	}
+
+	version, err := db.userVersion()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version != SchemaVersion() {
+		conn.Close()
+		return nil, fmt.Errorf("database is at schema version %d, not %d; run lazydocs to migrate it", version, SchemaVersion())
 	}
 
 	return db, nil
@@ -57,12 +89,6 @@ func (db *DB) Close() error {
 	return nil
 }
 
-// initSchema creates the database tables if they don't exist
-func (db *DB) initSchema() error {
-	_, err := db.conn.Exec(schema)
-	return err
-}
-
 // Conn returns the underlying database connection for advanced queries
 func (db *DB) Conn() *sql.DB {
 	return db.conn
@@ -0,0 +1,235 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lazydocs/lazydocs/internal/fuzzy"
+)
+
+// Suggest returns a respelling of query with each word that isn't in the
+// docsets searched replaced by the closest word that is, for "did you
+// mean" prompts. The docsets are chosen as for Search. It returns "" when
+// every word is known or nothing is close enough.
+func (s *Searcher) Suggest(query, docset, version string) (string, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return "", err
+	}
+
+	// Words of the docsets searched, as Search picks them
+	scope, scopeArgs := q.filters(docset, version, s.scope)
+
+	var replacements [][2]string
+	seen := make(map[string]bool)
+	for _, t := range q.Terms() {
+		for _, word := range strings.FieldsFunc(strings.ToLower(t), func(r rune) bool { return !isWordRune(r) }) {
+			if seen[word] || len([]rune(word)) < 3 || isNumber(word) {
+				continue
+			}
+			seen[word] = true
+
+			known, err := s.isKnownWord(word, scope, scopeArgs)
+			if err != nil {
+				return "", err
+			}
+			if known {
+				continue
+			}
+
+			closest, err := s.closestWord(word, scope, scopeArgs)
+			if err != nil {
+				return "", err
+			}
+			if closest != "" {
+				replacements = append(replacements, [2]string{word, closest})
+			}
+		}
+	}
+
+	if len(replacements) == 0 {
+		return "", nil
+	}
+	return respell(query, q, replacements), nil
+}
+
+// respell applies replacements to the free-text terms of q in input,
+// leaving filters, excluded terms and syntax as typed
+func respell(input string, q *Query, replacements [][2]string) string {
+	rs := []rune(input)
+
+	var b strings.Builder
+	last := 0
+	for _, g := range q.groups {
+		for _, t := range g {
+			if t.negate {
+				continue
+			}
+			text := string(rs[t.start:t.end])
+			for _, r := range replacements {
+				text = replaceWord(text, r[0], r[1])
+			}
+			b.WriteString(string(rs[last:t.start]))
+			b.WriteString(text)
+			last = t.end
+		}
+	}
+	b.WriteString(string(rs[last:]))
+	return b.String()
+}
+
+// isKnownWord reports whether word appears in any symbol, title or content
+// of the entries matching scope, conditions on entries e; "" means every
+// entry. Content is matched through the stemmed docs index, so other forms
+// of a word in it count too.
+func (s *Searcher) isKnownWord(word, scope string, scopeArgs []any) (bool, error) {
+	stmt := `
+		SELECT EXISTS (SELECT 1 FROM words_vocab WHERE term = :term)
+			OR EXISTS (SELECT 1 FROM docs WHERE docs MATCH :content)`
+	if scope != "" {
+		stmt = `
+			SELECT EXISTS (
+				SELECT 1 FROM words_instance v
+				JOIN entries e ON e.id = v.doc
+				WHERE v.term = :term` + scope + `
+			) OR EXISTS (
+				SELECT 1 FROM docs
+				JOIN entries e ON e.id = docs.rowid
+				WHERE docs MATCH :content` + scope + `
+			)`
+	}
+
+	var known bool
+	err := s.db.conn.QueryRow(stmt, append(scopeArgs,
+		sql.Named("term", word),
+		sql.Named("content", "content : "+quoteFTS5(word)))...).Scan(&known)
+	if err != nil {
+		return false, fmt.Errorf("vocabulary query failed: %w", err)
+	}
+	return known, nil
+}
+
+// maxFirstLetterTypo is the longest word whose first letter is also
+// respelled when looking for the closest word
+const maxFirstLetterTypo = 5
+
+// closestWord finds the word of the entries matching scope nearest to word
+// by edit distance. Ties go to the word found in more entries. Only words
+// of about the same length and with the same first letter are compared,
+// plus, for words of up to maxFirstLetterTypo letters, the words that
+// differ from it in the first letter alone, as in "xrray" for "array".
+func (s *Searcher) closestWord(word, scope string, scopeArgs []any) (string, error) {
+	length := len([]rune(word))
+	maxDist := maxEditDistance(length)
+
+	first, size := utf8.DecodeRuneInString(word)
+	counts, err := s.vocabCounts(`
+		v.term >= :first AND v.term < :next
+			AND length(v.term) BETWEEN :min_length AND :max_length`,
+		scope, append(scopeArgs,
+			sql.Named("first", string(first)),
+			sql.Named("next", string(first+1)),
+			sql.Named("min_length", length-maxDist),
+			sql.Named("max_length", length+maxDist)))
+	if err != nil {
+		return "", err
+	}
+
+	if length <= maxFirstLetterTypo {
+		var placeholders []string
+		args := scopeArgs
+		for _, r := range "abcdefghijklmnopqrstuvwxyz0123456789" {
+			if r == first {
+				continue
+			}
+			name := fmt.Sprintf("sub%d", len(placeholders))
+			placeholders = append(placeholders, ":"+name)
+			args = append(args, sql.Named(name, string(r)+word[size:]))
+		}
+		subs, err := s.vocabCounts("v.term IN ("+strings.Join(placeholders, ", ")+")", scope, args)
+		if err != nil {
+			return "", err
+		}
+		for term, docs := range subs {
+			counts[term] = docs
+		}
+	}
+
+	best, bestDist, bestDocs := "", maxDist+1, 0
+	for term, docs := range counts {
+		dist := fuzzy.EditDistance(word, term)
+		if dist < bestDist || (dist == bestDist && (docs > bestDocs || (docs == bestDocs && term < best))) {
+			best, bestDist, bestDocs = term, dist, docs
+		}
+	}
+	return best, nil
+}
+
+// vocabCounts returns the words of the entries matching scope that meet
+// cond, a condition on vocabulary rows v, with how many entries each is in
+func (s *Searcher) vocabCounts(cond, scope string, args []any) (map[string]int, error) {
+	stmt := "SELECT v.term, v.doc FROM words_vocab v WHERE " + cond
+	if scope != "" {
+		stmt = `
+			SELECT v.term, count(DISTINCT v.doc) FROM words_instance v
+			JOIN entries e ON e.id = v.doc
+			WHERE ` + cond + scope + `
+			GROUP BY v.term`
+	}
+
+	rows, err := s.db.conn.Query(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("vocabulary query failed: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var term string
+		var docs int
+		if err := rows.Scan(&term, &docs); err != nil {
+			return nil, fmt.Errorf("failed to scan term: %w", err)
+		}
+		counts[term] = docs
+	}
+	return counts, rows.Err()
+}
+
+// maxEditDistance is how many edits a word of the given length may be
+// from a suggestion; short words allow fewer so they stay recognizable
+func maxEditDistance(length int) int {
+	if length <= 4 {
+		return 1
+	}
+	return 2
+}
+
+// replaceWord replaces whole-word, case-insensitive occurrences of from
+// (already lowercase) in s with to
+func replaceWord(s, from, to string) string {
+	rs := []rune(s)
+	target := []rune(from)
+
+	var b strings.Builder
+	for i := 0; i < len(rs); {
+		if i+len(target) <= len(rs) &&
+			(i == 0 || !isWordRune(rs[i-1])) &&
+			(i+len(target) == len(rs) || !isWordRune(rs[i+len(target)])) &&
+			strings.ToLower(string(rs[i:i+len(target)])) == from {
+			b.WriteString(to)
+			i += len(target)
+			continue
+		}
+		b.WriteRune(rs[i])
+		i++
+	}
+	return b.String()
+}
+
+// isNumber reports whether s is all digits
+func isNumber(s string) bool {
+	return !strings.ContainsFunc(s, func(r rune) bool { return !unicode.IsDigit(r) })
+}
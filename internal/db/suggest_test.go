@@ -0,0 +1,55 @@
+//go:build sqlite_fts5
+
+package db
+
+import (
+	"testing"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+func TestSuggest(t *testing.T) {
+	database := openFixture(t,
+		entry("Array.prototype.filter", "array/filter", "# filter\n\nSkips filler elements."),
+		entry("Array.prototype.reduce", "array/reduce", "# reduce"),
+	)
+	py := []model.Entry{
+		{Docset: "py", Symbol: "filer.open", Title: "filer.open", Path: "filer#open"},
+		{Docset: "py", Symbol: "filer.close", Title: "filer.close", Path: "filer#close"},
+	}
+	if err := NewIndexer(database).IndexDocset(model.Docset{Slug: "py", Name: "py"}, py); err != nil {
+		t.Fatal(err)
+	}
+	s := NewSearcher(database)
+
+	tests := []struct {
+		query  string
+		docset string
+		want   string
+	}{
+		{"filtr", "js", "filter"},
+		{"filtr", "py", "filer"},
+		{"filtr", "", "filer"}, // Ties go to the word in more entries
+		{"filer", "", ""},
+		{"filer", "js", "filter"},
+		{"docset:js filtr", "", "docset:js filter"},
+		{"type:arra arra", "js", "type:arra array"}, // Filter values stay as typed
+		{`symbol:filtr "arra  filtr"`, "js", `symbol:filter "array  filter"`},
+		{"vilter", "js", ""},     // Candidates share the first letter
+		{"xrray", "js", "array"}, // Unless the word is short
+		{"Rrray", "js", "array"},
+		{"filler", "js", ""}, // Words of the content are known
+		{"filler", "py", "filer"},
+		{"reduce", "js", ""},
+	}
+	for _, tt := range tests {
+		got, err := s.Suggest(tt.query, tt.docset, "")
+		if err != nil {
+			t.Errorf("Suggest(%q, %q): %v", tt.query, tt.docset, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Suggest(%q, %q) = %q; want %q", tt.query, tt.docset, got, tt.want)
+		}
+	}
+}
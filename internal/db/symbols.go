@@ -0,0 +1,47 @@
+package db
+
+import (
+	"strings"
+	"unicode"
+)
+
+// symbolWords splits an identifier into lowercase words on camelCase,
+// snake_case and any punctuation ("." "::" "#" "()"), returned space
+// separated with a leading and trailing space so word-boundary matches can
+// use instr. "ActiveRecord::Base" becomes " active record base ".
+func symbolWords(symbol string) string {
+	var words []string
+	var word []rune
+
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(symbol)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// "querySelector" -> query|Selector, "HTMLElement" -> HTML|Element
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+
+		word = append(word, r)
+	}
+	flush()
+
+	if len(words) == 0 {
+		return ""
+	}
+	return " " + strings.Join(words, " ") + " "
+}
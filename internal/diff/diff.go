@@ -0,0 +1,214 @@
+// Package diff compares documents line by line and renders the result as a
+// unified or side-by-side diff.
+package diff
+
+import (
+	"strings"
+)
+
+// Op is the kind of change a line represents
+type Op int
+
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+// Line is one line of a diff
+type Line struct {
+	Op   Op
+	Text string
+	Old  int // Line number in the old text, 0 for inserts
+	New  int // Line number in the new text, 0 for deletes
+}
+
+// Hunk is a run of changes with unchanged lines of context around them
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []Line
+}
+
+// Lines diffs a and b line by line, using Myers' algorithm for a minimal
+// edit script
+func Lines(a, b string) []Line {
+	lines := myers(splitLines(a), splitLines(b))
+
+	old, cur := 0, 0
+	for i := range lines {
+		switch lines[i].Op {
+		case Equal:
+			old++
+			cur++
+			lines[i].Old, lines[i].New = old, cur
+		case Delete:
+			old++
+			lines[i].Old = old
+		case Insert:
+			cur++
+			lines[i].New = cur
+		}
+	}
+	return lines
+}
+
+// Changed reports whether a diff has any inserted or deleted lines
+func Changed(lines []Line) bool {
+	for _, l := range lines {
+		if l.Op != Equal {
+			return true
+		}
+	}
+	return false
+}
+
+// Hunks groups the changed lines of a diff with up to context unchanged
+// lines before and after each change
+func Hunks(lines []Line, context int) []Hunk {
+	var hunks []Hunk
+	for i := 0; i < len(lines); {
+		if lines[i].Op == Equal {
+			i++
+			continue
+		}
+
+		// Extend the hunk while changes are close enough to share context
+		start := max(i-context, 0)
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].Op != Equal {
+				end = j
+			} else if j-end > 2*context {
+				break
+			}
+		}
+		end = min(end+context+1, len(lines))
+
+		hunks = append(hunks, newHunk(lines, start, end))
+		i = end
+	}
+	return hunks
+}
+
+// newHunk computes the line ranges of the hunk lines[start:end]
+func newHunk(lines []Line, start, end int) Hunk {
+	h := Hunk{Lines: lines[start:end]}
+	for _, l := range h.Lines {
+		if l.Op != Insert {
+			if h.OldStart == 0 {
+				h.OldStart = l.Old
+			}
+			h.OldLines++
+		}
+		if l.Op != Delete {
+			if h.NewStart == 0 {
+				h.NewStart = l.New
+			}
+			h.NewLines++
+		}
+	}
+
+	// An empty side starts after the line before it, as in diff(1)
+	if h.OldStart == 0 {
+		h.OldStart = precedingLine(lines[:start], func(l Line) int { return l.Old })
+	}
+	if h.NewStart == 0 {
+		h.NewStart = precedingLine(lines[:start], func(l Line) int { return l.New })
+	}
+	return h
+}
+
+// precedingLine finds the last line number on one side among the lines
+// before a hunk, or 0 when the hunk starts that side
+func precedingLine(before []Line, side func(Line) int) int {
+	for i := len(before) - 1; i >= 0; i-- {
+		if n := side(before[i]); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// splitLines splits text into lines without their newlines
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// myers returns the shortest edit script turning a into b
+func myers(a, b []string) []Line {
+	n, m := len(a), len(b)
+	limit := n + m
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+
+	// trace[d] holds the furthest x on diagonals -d..d before step d
+	var trace [][]int
+
+search:
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk back from the end, collecting the script in reverse
+	var rev []Line
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			rev = append(rev, Line{Op: Equal, Text: a[x]})
+		}
+		if x == prevX {
+			y--
+			rev = append(rev, Line{Op: Insert, Text: b[y]})
+		} else {
+			x--
+			rev = append(rev, Line{Op: Delete, Text: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		rev = append(rev, Line{Op: Equal, Text: a[x]})
+	}
+
+	lines := make([]Line, len(rev))
+	for i, l := range rev {
+		lines[len(rev)-1-i] = l
+	}
+	return lines
+}
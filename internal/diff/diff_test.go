@@ -0,0 +1,155 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// script formats a diff as one "<op><text>" string per line, as in a
+// unified diff body
+func script(lines []Line) []string {
+	var out []string
+	for _, l := range lines {
+		switch l.Op {
+		case Equal:
+			out = append(out, " "+l.Text)
+		case Delete:
+			out = append(out, "-"+l.Text)
+		case Insert:
+			out = append(out, "+"+l.Text)
+		}
+	}
+	return out
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []string
+	}{
+		{name: "both empty", a: "", b: ""},
+		{name: "identical", a: "a\nb\n", b: "a\nb", want: []string{" a", " b"}},
+		{name: "all inserted", a: "", b: "a\nb\n", want: []string{"+a", "+b"}},
+		{name: "all deleted", a: "a\nb\n", b: "", want: []string{"-a", "-b"}},
+		{name: "changed line", a: "a\nb\nc\n", b: "a\nB\nc\n", want: []string{" a", "-b", "+B", " c"}},
+		{
+			name: "minimal script",
+			a:    "a\nb\nc\na\nb\nb\na\n",
+			b:    "c\nb\na\nb\na\nc\n",
+			want: []string{"-a", "-b", " c", "+b", " a", " b", "-b", " a", "+c"},
+		},
+	}
+
+	for _, tt := range tests {
+		lines := Lines(tt.a, tt.b)
+		if got := script(lines); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Lines = %q; want %q", tt.name, got, tt.want)
+		}
+		changed := slices.ContainsFunc(tt.want, func(l string) bool { return l[0] != ' ' })
+		if Changed(lines) != changed {
+			t.Errorf("%s: Changed = %v; want %v", tt.name, Changed(lines), changed)
+		}
+	}
+}
+
+func TestLinesNumbers(t *testing.T) {
+	lines := Lines("a\nb\nc\n", "a\nx\ny\nc\n")
+	want := []Line{
+		{Equal, "a", 1, 1},
+		{Delete, "b", 2, 0},
+		{Insert, "x", 0, 2},
+		{Insert, "y", 0, 3},
+		{Equal, "c", 3, 4},
+	}
+	if !slices.Equal(lines, want) {
+		t.Errorf("Lines = %+v; want %+v", lines, want)
+	}
+}
+
+// numbered returns n lines "1".."n", with the given lines replaced
+func numbered(n int, replace map[int]string) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		line := fmt.Sprint(i)
+		if r, ok := replace[i]; ok {
+			line = r
+		}
+		if line != "" {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+func TestHunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    []string // Hunk headers as "-start,lines +start,lines"
+	}{
+		{name: "unchanged", a: numbered(10, nil), b: numbered(10, nil), context: 3},
+		{
+			name:    "single change",
+			a:       numbered(20, nil),
+			b:       numbered(20, map[int]string{10: "ten"}),
+			context: 3,
+			want:    []string{"-7,7 +7,7"},
+		},
+		{
+			name:    "context clipped at the edges",
+			a:       numbered(5, nil),
+			b:       numbered(5, map[int]string{1: "one", 5: "five"}),
+			context: 3,
+			want:    []string{"-1,5 +1,5"},
+		},
+		{
+			name:    "changes sharing context merge",
+			a:       numbered(30, nil),
+			b:       numbered(30, map[int]string{10: "ten", 16: "sixteen"}),
+			context: 3,
+			want:    []string{"-7,13 +7,13"},
+		},
+		{
+			name:    "distant changes stay apart",
+			a:       numbered(30, nil),
+			b:       numbered(30, map[int]string{10: "ten", 18: "eighteen"}),
+			context: 3,
+			want:    []string{"-7,7 +7,7", "-15,7 +15,7"},
+		},
+		{
+			name:    "no context",
+			a:       numbered(10, nil),
+			b:       numbered(10, map[int]string{3: "three", 4: "four", 8: "eight"}),
+			context: 0,
+			want:    []string{"-3,2 +3,2", "-8,1 +8,1"},
+		},
+		{
+			name:    "pure delete at the end",
+			a:       numbered(5, nil),
+			b:       numbered(3, nil),
+			context: 0,
+			want:    []string{"-4,2 +3,0"},
+		},
+		{
+			name:    "pure insert starts after the preceding line",
+			a:       numbered(5, nil),
+			b:       strings.Replace(numbered(5, nil), "3\n", "3\nnew\n", 1),
+			context: 0,
+			want:    []string{"-3,0 +4,1"},
+		},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, h := range Hunks(Lines(tt.a, tt.b), tt.context) {
+			got = append(got, fmt.Sprintf("-%d,%d +%d,%d", h.OldStart, h.OldLines, h.NewStart, h.NewLines))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Hunks = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// ANSI colors for rendered diffs
+const (
+	colorReset  = "\x1b[0m"
+	colorDelete = "\x1b[31m"
+	colorInsert = "\x1b[32m"
+	colorHunk   = "\x1b[36m"
+	colorHeader = "\x1b[1m"
+)
+
+// Options controls how a diff is rendered
+type Options struct {
+	Color bool // Color insertions, deletions and headers with ANSI codes
+	Width int  // Total width for side-by-side output
+}
+
+// Unified writes hunks in unified diff format
+func Unified(w io.Writer, oldName, newName string, hunks []Hunk, opts Options) error {
+	p := printer{w: w, color: opts.Color}
+	p.line(colorHeader, "--- "+oldName)
+	p.line(colorHeader, "+++ "+newName)
+
+	for _, h := range hunks {
+		p.line(colorHunk, fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines)))
+		for _, l := range h.Lines {
+			switch l.Op {
+			case Equal:
+				p.line("", " "+l.Text)
+			case Delete:
+				p.line(colorDelete, "-"+l.Text)
+			case Insert:
+				p.line(colorInsert, "+"+l.Text)
+			}
+		}
+	}
+	return p.err
+}
+
+// SideBySide writes hunks as two columns, old on the left and new on the
+// right. Deleted lines are paired with the lines inserted in their place.
+func SideBySide(w io.Writer, oldName, newName string, hunks []Hunk, opts Options) error {
+	width := opts.Width
+	if width < 40 {
+		width = 80
+	}
+	col := (width - 3) / 2
+
+	p := printer{w: w, color: opts.Color}
+	p.line(colorHeader, fit(oldName, col)+" | "+fit(newName, col))
+
+	for i, h := range hunks {
+		if i > 0 {
+			p.line(colorHunk, strings.Repeat("-", col)+"-+-"+strings.Repeat("-", col))
+		}
+
+		lines := h.Lines
+		for len(lines) > 0 {
+			if lines[0].Op == Equal {
+				p.line("", fit(lines[0].Text, col)+"   "+fit(lines[0].Text, col))
+				lines = lines[1:]
+				continue
+			}
+
+			// Collect a block of deletes and inserts and pair them up
+			var dels, ins []string
+			for len(lines) > 0 && lines[0].Op != Equal {
+				if lines[0].Op == Delete {
+					dels = append(dels, lines[0].Text)
+				} else {
+					ins = append(ins, lines[0].Text)
+				}
+				lines = lines[1:]
+			}
+			for j := 0; j < max(len(dels), len(ins)); j++ {
+				left, right, mark := "", "", " | "
+				if j < len(dels) {
+					left = dels[j]
+				} else {
+					mark = " > "
+				}
+				if j < len(ins) {
+					right = ins[j]
+				} else {
+					mark = " < "
+				}
+				p.pair(fit(left, col), mark, fit(right, col))
+			}
+		}
+	}
+	return p.err
+}
+
+// hunkRange formats a hunk's line range as in diff(1)
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// fit pads or truncates s to exactly width columns
+func fit(s string, width int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	if runewidth.StringWidth(s) > width {
+		return runewidth.Truncate(s, width, "…")
+	}
+	return runewidth.FillRight(s, width)
+}
+
+// printer writes lines, remembering the first error
+type printer struct {
+	w     io.Writer
+	color bool
+	err   error
+}
+
+func (p *printer) line(color, text string) {
+	if p.err != nil {
+		return
+	}
+	if p.color && color != "" {
+		text = color + text + colorReset
+	}
+	_, p.err = fmt.Fprintln(p.w, text)
+}
+
+// pair writes a changed side-by-side row, coloring each side
+func (p *printer) pair(left, mark, right string) {
+	if p.color {
+		left = colorDelete + left + colorReset
+		right = colorInsert + right + colorReset
+	}
+	p.line("", left+mark+right)
+}
@@ -0,0 +1,123 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "changed line",
+			a:    "a\nb\nc\n",
+			b:    "a\nB\nc\n",
+			want: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name: "single line ranges drop the count",
+			a:    "a\n",
+			b:    "b\n",
+			want: "--- old\n+++ new\n@@ -1 +1 @@\n-a\n+b\n",
+		},
+		{
+			name: "insert into empty",
+			a:    "",
+			b:    "a\nb\n",
+			want: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{name: "unchanged", a: "a\n", b: "a\n", want: "--- old\n+++ new\n"},
+	}
+
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := Unified(&b, "old", "new", Hunks(Lines(tt.a, tt.b), 3), Options{}); err != nil {
+			t.Fatalf("%s: Unified: %v", tt.name, err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("%s: Unified =\n%s\nwant\n%s", tt.name, b.String(), tt.want)
+		}
+	}
+}
+
+func TestUnifiedColor(t *testing.T) {
+	var b strings.Builder
+	if err := Unified(&b, "old", "new", Hunks(Lines("a\n", "b\n"), 3), Options{Color: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{colorDelete + "-a" + colorReset, colorInsert + "+b" + colorReset} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Unified with color = %q; want it to contain %q", b.String(), want)
+		}
+	}
+}
+
+// row formats a side-by-side row with 20-column sides, as TestSideBySide
+// compares them
+func row(left, mark, right string) string {
+	return strings.TrimRight(runewidth.FillRight(left, 20)+mark+right, " ")
+}
+
+func TestSideBySide(t *testing.T) {
+	const width = 43 // Two 20-column sides and a 3-column gutter
+
+	tests := []struct {
+		name string
+		a, b string
+		want []string // Rows with trailing padding trimmed
+	}{
+		{
+			name: "paired change",
+			a:    "a\nb\n",
+			b:    "a\nB\n",
+			want: []string{row("old", " | ", "new"), row("a", "   ", "a"), row("b", " | ", "B")},
+		},
+		{
+			name: "more inserts than deletes",
+			a:    "a\n",
+			b:    "x\ny\n",
+			want: []string{row("old", " | ", "new"), row("a", " | ", "x"), row("", " > ", "y")},
+		},
+		{
+			name: "more deletes than inserts",
+			a:    "a\nb\n",
+			b:    "",
+			want: []string{row("old", " | ", "new"), row("a", " <", ""), row("b", " <", "")},
+		},
+	}
+
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := SideBySide(&b, "old", "new", Hunks(Lines(tt.a, tt.b), 3), Options{Width: width}); err != nil {
+			t.Fatalf("%s: SideBySide: %v", tt.name, err)
+		}
+		var got []string
+		for _, row := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+			got = append(got, strings.TrimRight(row, " "))
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: SideBySide =\n%s\nwant\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestSideBySideFitsWidth(t *testing.T) {
+	const width = 43
+	long := strings.Repeat("x", 100)
+
+	var b strings.Builder
+	hunks := Hunks(Lines("a\n"+long+"\n", "a\n"+long+"\n"+long+"y\n"), 3)
+	if err := SideBySide(&b, "old", "new", hunks, Options{Width: width}); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		if w := runewidth.StringWidth(row); w > width {
+			t.Errorf("row %q is %d columns wide; want at most %d", row, w, width)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package fuzzy
+
+import "testing"
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"map", "map", 0},
+		{"", "map", 3},
+		{"map", "mop", 1},
+		{"map", "maps", 1},
+		{"filter", "fitler", 1}, // Adjacent transposition
+		{"kitten", "sitting", 3},
+		{"ca", "abc", 3}, // Optimal string alignment, not full Damerau
+		{"héllo", "hello", 1},
+	}
+
+	for _, tt := range tests {
+		if got := EditDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("EditDistance(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := EditDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("EditDistance(%q, %q) = %d; want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
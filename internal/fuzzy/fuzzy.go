@@ -0,0 +1,212 @@
+package fuzzy
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Scoring constants, modelled on fzf's defaults
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	bonusBoundary    = scoreMatch / 2 // After a separator or at the start
+	bonusCamel       = bonusBoundary - 1
+	bonusConsecutive = -(scoreGapStart + scoreGapExtension)
+
+	// The first pattern character counts double, so "arr" prefers
+	// "Array" over "bar_r"
+	bonusFirstCharMultiplier = 2
+)
+
+// Result is a candidate that matched the pattern
+type Result struct {
+	Index     int   // Position of the candidate in the input slice
+	Score     int   // Higher is better
+	Positions []int // Matched rune offsets in the candidate
+}
+
+// Match scores candidate against pattern. Every pattern character must
+// appear in order; matches at word boundaries, camelCase humps and runs
+// of consecutive characters score higher. Matching is case-insensitive
+// unless the pattern contains an uppercase letter.
+func Match(pattern, candidate string) (score int, positions []int, ok bool) {
+	p := []rune(pattern)
+	c := []rune(candidate)
+	if len(p) == 0 || len(p) > len(c) {
+		return 0, nil, false
+	}
+
+	caseSensitive := hasUpper(p)
+	if !caseSensitive {
+		p = lowerRunes(p)
+	}
+	folded := c
+	if !caseSensitive {
+		folded = lowerRunes(c)
+	}
+
+	// Cheap subsequence check rejects most candidates before scoring
+	if !isSubsequence(p, folded) {
+		return 0, nil, false
+	}
+
+	n, m := len(p), len(c)
+	bonus := make([]int, m)
+	for j := range c {
+		bonus[j] = bonusAt(c, j)
+	}
+
+	// dp[i][j] is the best score with p[i] matched at c[j];
+	// from[i][j] is where p[i-1] was matched on that best path
+	const none = -1 << 30
+	dp := make([][]int, n)
+	from := make([][]int, n)
+	for i := range dp {
+		dp[i] = make([]int, m)
+		from[i] = make([]int, m)
+		for j := range dp[i] {
+			dp[i][j] = none
+			from[i][j] = -1
+		}
+	}
+
+	for j := 0; j < m; j++ {
+		if folded[j] == p[0] {
+			dp[0][j] = scoreMatch + bonus[j]*bonusFirstCharMultiplier
+		}
+	}
+
+	for i := 1; i < n; i++ {
+		// Best dp[i-1][k] for k < j-1, less the gap penalty to j
+		gapBest, gapFrom := none, -1
+		for j := i; j < m; j++ {
+			if j >= 2 {
+				if gapBest != none {
+					gapBest += scoreGapExtension
+				}
+				if prev := dp[i-1][j-2]; prev != none && prev+scoreGapStart > gapBest {
+					gapBest, gapFrom = prev+scoreGapStart, j-2
+				}
+			}
+
+			if folded[j] != p[i] {
+				continue
+			}
+
+			if gapBest != none {
+				dp[i][j] = gapBest + scoreMatch + bonus[j]
+				from[i][j] = gapFrom
+			}
+
+			if prev := dp[i-1][j-1]; prev != none {
+				consecutive := prev + scoreMatch + max(bonus[j], bonusConsecutive)
+				if consecutive >= dp[i][j] {
+					dp[i][j] = consecutive
+					from[i][j] = j - 1
+				}
+			}
+		}
+	}
+
+	best, end := none, -1
+	for j := n - 1; j < m; j++ {
+		if dp[n-1][j] > best {
+			best, end = dp[n-1][j], j
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	positions = make([]int, n)
+	for i, j := n-1, end; i >= 0; i-- {
+		positions[i] = j
+		j = from[i][j]
+	}
+
+	return best, positions, true
+}
+
+// Find matches pattern against every candidate and returns the best
+// matches, highest score first. Ties go to the shorter candidate.
+func Find(pattern string, candidates []string, limit int) []Result {
+	var results []Result
+	for i, candidate := range candidates {
+		score, positions, ok := Match(pattern, candidate)
+		if !ok {
+			continue
+		}
+		results = append(results, Result{Index: i, Score: score, Positions: positions})
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		if results[a].Score != results[b].Score {
+			return results[a].Score > results[b].Score
+		}
+		return len(candidates[results[a].Index]) < len(candidates[results[b].Index])
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// bonusAt returns the position bonus for matching c[j]
+func bonusAt(c []rune, j int) int {
+	cur := c[j]
+	if !isWord(cur) {
+		return 0
+	}
+	if j == 0 {
+		return bonusBoundary
+	}
+
+	prev := c[j-1]
+	switch {
+	case !isWord(prev):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return bonusCamel
+	case unicode.IsLetter(prev) && unicode.IsDigit(cur):
+		return bonusCamel
+	}
+	return 0
+}
+
+// isWord reports whether r is part of an identifier word
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isSubsequence reports whether p appears in c in order
+func isSubsequence(p, c []rune) bool {
+	i := 0
+	for _, r := range c {
+		if i < len(p) && r == p[i] {
+			i++
+		}
+	}
+	return i == len(p)
+}
+
+// hasUpper reports whether any rune is uppercase
+func hasUpper(rs []rune) bool {
+	for _, r := range rs {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerRunes returns a lowercased copy of rs
+func lowerRunes(rs []rune) []rune {
+	out := make([]rune, len(rs))
+	for i, r := range rs {
+		out[i] = unicode.ToLower(r)
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package fuzzy
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, candidate string
+		ok                 bool
+		positions          []int
+	}{
+		{"amap", "Array.prototype.map", true, []int{0, 16, 17, 18}},
+		{"qs", "querySelector", true, []int{0, 5}},
+		{"map", "MAP", true, []int{0, 1, 2}},
+		{"Map", "map", false, nil},
+		{"pam", "map", false, nil},
+		{"", "map", false, nil},
+	}
+
+	for _, tt := range tests {
+		_, positions, ok := Match(tt.pattern, tt.candidate)
+		if ok != tt.ok || !slices.Equal(positions, tt.positions) {
+			t.Errorf("Match(%q, %q) = %v, %v; want %v, %v", tt.pattern, tt.candidate, positions, ok, tt.positions, tt.ok)
+		}
+	}
+}
+
+func TestMatchPrefersBoundaries(t *testing.T) {
+	tests := []struct {
+		pattern, better, worse string
+	}{
+		{"qs", "querySelector", "quests"},
+		{"map", "Array.prototype.map", "Array.prototype.flatMap"},
+		{"ar", "Array", "Boolean.prototype.toString"},
+	}
+
+	for _, tt := range tests {
+		better, _, ok1 := Match(tt.pattern, tt.better)
+		worse, _, ok2 := Match(tt.pattern, tt.worse)
+		if !ok1 || !ok2 || better <= worse {
+			t.Errorf("Match(%q): %q scored %d, %q scored %d; want the first higher",
+				tt.pattern, tt.better, better, tt.worse, worse)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	got := Find("map", []string{"flatMap", "Map", "filter", "map"}, 2)
+	if len(got) != 2 || got[0].Index != 1 || got[1].Index != 3 {
+		t.Errorf("Find(map) = %+v; want Map, then map, ahead of flatMap", got)
+	}
+}
@@ -2,16 +2,17 @@ package model
 
 import "time"
 
-// Docset represents an installed documentation set
+// Docset represents an installed documentation set. The JSON field names
+// are part of the CLI's --json output and must stay stable.
 type Docset struct {
-	ID          int64
-	Slug        string // e.g., "rails~7.1"
-	Name        string // e.g., "rails"
-	Version     string // e.g., "7.1" (empty for unversioned)
-	DisplayName string // e.g., "Ruby on Rails"
-	EntryCount  int
-	Mtime       int64     // DevDocs modification time
-	InstalledAt time.Time // When we installed it
+	ID          int64     `json:"id"`
+	Slug        string    `json:"slug"`         // e.g., "rails~7.1"
+	Name        string    `json:"name"`         // e.g., "rails"
+	Version     string    `json:"version"`      // e.g., "7.1" (empty for unversioned)
+	DisplayName string    `json:"display_name"` // e.g., "Ruby on Rails"
+	EntryCount  int       `json:"entry_count"`
+	Mtime       int64     `json:"mtime"`        // DevDocs modification time
+	InstalledAt time.Time `json:"installed_at"` // When we installed it
 }
 
 // FullSlug returns the slug with version if applicable
@@ -21,3 +22,25 @@ func (d Docset) FullSlug() string {
 	}
 	return d.Name
 }
+
+// Changelog lists how the symbols of a docset differ between two versions,
+// or between an install and the one it replaced
+type Changelog struct {
+	From    string         `json:"from"` // Slug, or "<slug> (previous install)"
+	To      string         `json:"to"`
+	Added   []SymbolChange `json:"added"`
+	Removed []SymbolChange `json:"removed"`
+	Changed []SymbolChange `json:"changed"`
+}
+
+// SymbolChange is a symbol that was added, removed or changed
+type SymbolChange struct {
+	Symbol string `json:"symbol"`
+	Type   string `json:"type"`
+	Path   string `json:"path"`
+}
+
+// Empty reports whether nothing changed
+func (c *Changelog) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
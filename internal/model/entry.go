@@ -1,18 +1,74 @@
 package model
 
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
 // Entry represents a documentation entry
 type Entry struct {
-	Docset  string // e.g., "rails"
-	Version string // e.g., "7.1" (empty for unversioned)
-	Symbol  string // e.g., "ActiveRecord::Base"
-	Title   string // Display title
-	Content string // Full markdown content
-	Path    string // Original path in docset
+	ID      int64  `json:"id"`                // Row ID in the index
+	Docset  string `json:"docset"`            // e.g., "rails"
+	Version string `json:"version"`           // e.g., "7.1" (empty for unversioned)
+	Symbol  string `json:"symbol"`            // e.g., "ActiveRecord::Base"
+	Type    string `json:"type"`              // DevDocs entry type, e.g., "Array" or "Methods"
+	Title   string `json:"title"`             // Display title
+	Content string `json:"content,omitempty"` // Full markdown content (only loaded by GetEntry)
+	Path    string `json:"path"`              // Original path in docset
+}
+
+// Cursor encodes the entry, as the last of a page of entries, into an
+// opaque cursor for the page after it. The server's "next" and the
+// client's "after" must agree on it.
+func (e Entry) Cursor() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(e.ID, 10) + ":" + e.Symbol))
+}
+
+// ParseCursor reverses Entry.Cursor; an empty cursor is the first page
+func ParseCursor(cursor string) (*Entry, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("malformed after cursor")
+	}
+	id, symbol, ok := strings.Cut(string(data), ":")
+	n, err := strconv.ParseInt(id, 10, 64)
+	if !ok || err != nil {
+		return nil, errors.New("malformed after cursor")
+	}
+	return &Entry{ID: n, Symbol: symbol}, nil
+}
+
+// Heading is a section heading within an entry's content
+type Heading struct {
+	Level int    `json:"level"` // 1 for "#" through 4 for "####"
+	Text  string `json:"text"`  // Heading text without Markdown formatting
+}
+
+// Example is a fenced code block from an entry
+type Example struct {
+	ID       int64   `json:"id"`
+	Entry    Entry   `json:"entry"`    // Entry the block appears in, without content
+	Position int     `json:"position"` // Index of the block within the entry
+	Language string  `json:"language"` // From the fence info string, e.g. "ruby"; may be empty
+	Code     string  `json:"code"`
+	Rank     float64 `json:"rank"` // BM25 rank score, when found by search
 }
 
 // SearchResult represents a search result with ranking info
 type SearchResult struct {
 	Entry
-	Rank    float64 // BM25 rank score
-	Snippet string  // Highlighted snippet from content
+	Rank    float64 `json:"rank"`    // BM25 rank score
+	Snippet string  `json:"snippet"` // Highlighted snippet from content
+}
+
+// FuzzyResult represents an entry found by the fuzzy symbol matcher
+type FuzzyResult struct {
+	Entry
+	Score     int   `json:"score"`     // Match score, higher is better
+	Positions []int `json:"positions"` // Matched rune offsets in Symbol
 }
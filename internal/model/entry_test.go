@@ -0,0 +1,30 @@
+package model
+
+import "testing"
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, e := range []Entry{
+		{ID: 1, Symbol: "map"},
+		{ID: 42, Symbol: "ActiveRecord::Base#save"},
+		{ID: 7, Symbol: "a:b:c"},
+		{ID: 9, Symbol: ""},
+	} {
+		got, err := ParseCursor(e.Cursor())
+		if err != nil {
+			t.Errorf("ParseCursor(%+v.Cursor()): %v", e, err)
+			continue
+		}
+		if got.ID != e.ID || got.Symbol != e.Symbol {
+			t.Errorf("round trip of %+v = %+v", e, got)
+		}
+	}
+
+	if e, err := ParseCursor(""); e != nil || err != nil {
+		t.Errorf("ParseCursor(\"\") = %v, %v; want the first page", e, err)
+	}
+	for _, bad := range []string{"!!", "bWFw" /* "map" */} {
+		if _, err := ParseCursor(bad); err == nil {
+			t.Errorf("ParseCursor(%q) succeeded", bad)
+		}
+	}
+}
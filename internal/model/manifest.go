@@ -1,5 +1,10 @@
 package model
 
+import (
+	"strconv"
+	"strings"
+)
+
 // ManifestEntry represents a docset in the DevDocs manifest
 type ManifestEntry struct {
 	Name    string `json:"name"`    // e.g., "Ruby on Rails"
@@ -23,3 +28,32 @@ func ParseSlug(slug string) (name, version string) {
 	}
 	return slug, ""
 }
+
+// CompareVersions orders two docset versions like "7.1" and "7.0.1" part
+// by part, numerically where both parts are numbers, so "3.10" comes after
+// "3.9". It returns -1, 0 or +1 like strings.Compare.
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, errX := strconv.Atoi(as[i])
+		y, errY := strconv.Atoi(bs[i])
+		switch {
+		case errX == nil && errY == nil && x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		case errX != nil || errY != nil:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
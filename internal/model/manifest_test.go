@@ -0,0 +1,27 @@
+package model
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"7.1", "7.1", 0},
+		{"7.0", "7.1", -1},
+		{"8.0", "7.1", 1},
+		{"3.9", "3.10", -1},
+		{"7", "7.1", -1},
+		{"18", "9", 1},
+		{"", "1", -1},
+		{"5.x", "5.1", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d; want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
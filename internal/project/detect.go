@@ -0,0 +1,382 @@
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+// Requirement is a language or library a project uses, named by its
+// DevDocs docset
+type Requirement struct {
+	Docset  string // Slug without version, e.g. "rails"
+	Package string // Name in the dependency file, e.g. "rails"
+	Version string // Version or constraint as written, e.g. "7.1.3"; may be empty
+	Source  string // Dependency file, e.g. "Gemfile.lock"
+}
+
+// Suggestion is an available docset for a requirement
+type Suggestion struct {
+	Slug      string `json:"slug"`
+	Source    string `json:"source"` // Dependency file, e.g. "Gemfile.lock"
+	Reason    string `json:"reason"` // What was found in it, e.g. "rails 7.1.3"
+	Installed bool   `json:"installed"`
+}
+
+// detectors read the dependency files of each ecosystem, in the order
+// their suggestions are listed
+var detectors = []struct {
+	file  string
+	parse func(data []byte) ([]Requirement, error)
+}{
+	{"go.mod", parseGoMod},
+	{"package.json", parsePackageJSON},
+	{"Gemfile.lock", parseGemfileLock},
+	{"pyproject.toml", parsePyproject},
+	{"Cargo.toml", parseCargoToml},
+}
+
+// Package names that have a docset, by ecosystem
+var (
+	npmDocsets = map[string]string{
+		"react": "react", "vue": "vue", "@angular/core": "angular", "svelte": "svelte",
+		"typescript": "typescript", "express": "express", "jquery": "jquery",
+		"lodash": "lodash", "d3": "d3", "redux": "redux", "webpack": "webpack",
+		"jest": "jest", "axios": "axios", "vite": "vite", "tailwindcss": "tailwindcss",
+		"bootstrap": "bootstrap", "moment": "moment", "eslint": "eslint",
+	}
+	gemDocsets = map[string]string{
+		"rails": "rails", "sinatra": "sinatra", "minitest": "minitest",
+		"rspec-core": "rspec", "nokogiri": "nokogiri",
+	}
+	pythonDocsets = map[string]string{
+		"django": "django", "flask": "flask", "numpy": "numpy", "pandas": "pandas",
+		"requests": "requests", "scikit-learn": "scikit_learn", "matplotlib": "matplotlib",
+		"torch": "pytorch", "tensorflow": "tensorflow", "sqlalchemy": "sqlalchemy",
+		"click": "click", "jinja2": "jinja",
+	}
+)
+
+// versionNumber matches the first version number in a version or
+// constraint, e.g. "7.1" in "~> 7.1"
+var versionNumber = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// FindRoot returns the nearest directory, starting at dir and walking up,
+// that holds a dependency file lazydocs reads; "" when there is none
+func FindRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, d := range detectors {
+			if _, err := os.Stat(filepath.Join(dir, d.file)); err == nil {
+				return dir, nil
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return "", err
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Requirements reads the dependency files in dir
+func Requirements(dir string) ([]Requirement, error) {
+	var reqs []Requirement
+	for _, d := range detectors {
+		data, err := os.ReadFile(filepath.Join(dir, d.file))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		found, err := d.parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", d.file, err)
+		}
+		for i := range found {
+			found[i].Source = d.file
+		}
+		reqs = append(reqs, found...)
+	}
+	return reqs, nil
+}
+
+// Match picks an available docset for each requirement: the version the
+// project's version starts with, else the newest. Requirements without a
+// docset in the manifest are dropped.
+func Match(reqs []Requirement, manifest model.Manifest) []Suggestion {
+	var suggestions []Suggestion
+	for _, req := range reqs {
+		entry := bestEntry(req, manifest)
+		if entry == nil || slices.ContainsFunc(suggestions, func(s Suggestion) bool { return s.Slug == entry.Slug }) {
+			continue
+		}
+
+		reason := req.Package
+		if req.Version != "" {
+			reason += " " + req.Version
+		}
+		suggestions = append(suggestions, Suggestion{Slug: entry.Slug, Source: req.Source, Reason: reason})
+	}
+	return suggestions
+}
+
+// bestEntry returns the manifest entry of the requirement's docset whose
+// version matches the most leading parts of the required version, the
+// newest among equals
+func bestEntry(req Requirement, manifest model.Manifest) *model.ManifestEntry {
+	want := versionParts(req.Version)
+
+	var best *model.ManifestEntry
+	bestScore := -1
+	for i := range manifest {
+		entry := &manifest[i]
+		if name, _ := model.ParseSlug(entry.Slug); name != req.Docset {
+			continue
+		}
+
+		score := 0
+		if have := versionParts(entry.Version); len(have) > 0 && len(have) <= len(want) && slices.Equal(have, want[:len(have)]) {
+			score = len(have)
+		}
+		if score > bestScore || score == bestScore && compareVersions(entry, best) > 0 {
+			best, bestScore = entry, score
+		}
+	}
+	return best
+}
+
+// compareVersions orders two entries of a docset by version
+func compareVersions(a, b *model.ManifestEntry) int {
+	return slices.Compare(versionParts(a.Version), versionParts(b.Version))
+}
+
+// versionParts returns the numbers of the first version in s, e.g. [3 12]
+// for ">=3.12"
+func versionParts(s string) []int {
+	var parts []int
+	for _, p := range strings.Split(versionNumber.FindString(s), ".") {
+		if n, err := strconv.Atoi(p); err == nil {
+			parts = append(parts, n)
+		}
+	}
+	return parts
+}
+
+func parseGoMod(data []byte) ([]Requirement, error) {
+	req := Requirement{Docset: "go", Package: "go"}
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "go "); ok {
+			req.Version = strings.TrimSpace(v)
+			break
+		}
+	}
+	return []Requirement{req}, nil
+}
+
+func parsePackageJSON(data []byte) ([]Requirement, error) {
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+		Engines         map[string]string `json:"engines"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+
+	reqs := []Requirement{{Docset: "javascript", Package: "javascript"}}
+	if v, ok := pkg.Engines["node"]; ok {
+		reqs = append(reqs, Requirement{Docset: "node", Package: "node", Version: v})
+	}
+
+	deps := make(map[string]string)
+	for name, v := range pkg.DevDependencies {
+		deps[name] = v
+	}
+	for name, v := range pkg.Dependencies {
+		deps[name] = v
+	}
+	return append(reqs, known(deps, npmDocsets)...), nil
+}
+
+func parseGemfileLock(data []byte) ([]Requirement, error) {
+	ruby := Requirement{Docset: "ruby", Package: "ruby"}
+	gems := make(map[string]string)
+
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" && !strings.HasPrefix(line, " ") {
+			section = line
+			continue
+		}
+
+		switch {
+		case section == "RUBY VERSION":
+			if v, ok := strings.CutPrefix(strings.TrimSpace(line), "ruby "); ok {
+				ruby.Version = v
+			}
+		case section == "GEM" && strings.HasPrefix(line, "    ") && !strings.HasPrefix(line, "     "):
+			// "    rails (7.1.3)"; deeper lines are the gem's own dependencies
+			name, v, _ := strings.Cut(strings.TrimSpace(line), " ")
+			gems[name] = strings.Trim(v, "()")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return append([]Requirement{ruby}, known(gems, gemDocsets)...), nil
+}
+
+// parsePyproject reads the Python version and dependencies of PEP 621 and
+// Poetry projects. Only the few TOML forms they're written in are
+// understood.
+func parsePyproject(data []byte) ([]Requirement, error) {
+	python := Requirement{Docset: "python", Package: "python"}
+	deps := make(map[string]string)
+
+	section := ""
+	inDependencies := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Entries of a multi-line dependencies array
+		if inDependencies {
+			for _, spec := range quoted(line) {
+				name, v := splitRequirement(spec)
+				deps[name] = v
+			}
+			inDependencies = !strings.Contains(line, "]")
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case section == "project" && key == "requires-python":
+			python.Version = unquote(value)
+		case section == "project" && key == "dependencies":
+			for _, spec := range quoted(value) {
+				name, v := splitRequirement(spec)
+				deps[name] = v
+			}
+			inDependencies = !strings.Contains(value, "]")
+		case section == "tool.poetry.dependencies" && key == "python":
+			python.Version = unquote(value)
+		case section == "tool.poetry.dependencies":
+			// django = "^4.2" or django = { version = "^4.2", ... }
+			v := unquote(value)
+			if strings.HasPrefix(value, "{") {
+				if _, rest, ok := strings.Cut(value, "version"); ok {
+					v = first(quoted(rest))
+				}
+			}
+			deps[strings.ToLower(key)] = v
+		}
+	}
+
+	return append([]Requirement{python}, known(deps, pythonDocsets)...), nil
+}
+
+func parseCargoToml(data []byte) ([]Requirement, error) {
+	rust := Requirement{Docset: "rust", Package: "rust"}
+
+	section := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && section == "package" && strings.TrimSpace(key) == "rust-version" {
+			rust.Version = unquote(strings.TrimSpace(value))
+		}
+	}
+	return []Requirement{rust}, nil
+}
+
+// known returns requirements for the dependencies that have a docset,
+// sorted by package name
+func known(deps map[string]string, docsets map[string]string) []Requirement {
+	var reqs []Requirement
+	for name, v := range deps {
+		if docset, ok := docsets[name]; ok {
+			reqs = append(reqs, Requirement{Docset: docset, Package: name, Version: v})
+		}
+	}
+	slices.SortFunc(reqs, func(a, b Requirement) int { return strings.Compare(a.Package, b.Package) })
+	return reqs
+}
+
+// splitRequirement splits a PEP 508 requirement like "Django>=4.2,<5" into
+// its lowercased name and the rest
+func splitRequirement(spec string) (name, version string) {
+	i := strings.IndexFunc(spec, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if i < 0 {
+		return strings.ToLower(spec), ""
+	}
+	return strings.ToLower(spec[:i]), strings.TrimSpace(spec[i:])
+}
+
+// quotedString matches a double- or single-quoted TOML string
+var quotedString = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+
+// quoted returns the contents of the quoted strings in s
+func quoted(s string) []string {
+	var values []string
+	for _, m := range quotedString.FindAllStringSubmatch(s, -1) {
+		values = append(values, m[1]+m[2])
+	}
+	return values
+}
+
+// unquote returns the first quoted string in s, or s itself
+func unquote(s string) string {
+	if values := quoted(s); len(values) > 0 {
+		return values[0]
+	}
+	return s
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,245 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+func TestParsers(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func([]byte) ([]Requirement, error)
+		data  string
+		want  []Requirement
+	}{
+		{
+			name:  "go.mod",
+			parse: parseGoMod,
+			data:  "module example.com/app\n\ngo 1.22.1\n\ntoolchain go1.23.0\n\nrequire golang.org/x/text v0.14.0\n",
+			want:  []Requirement{{Docset: "go", Package: "go", Version: "1.22.1"}},
+		},
+		{
+			name:  "go.mod without a go line",
+			parse: parseGoMod,
+			data:  "module example.com/app\n",
+			want:  []Requirement{{Docset: "go", Package: "go"}},
+		},
+		{
+			name:  "package.json",
+			parse: parsePackageJSON,
+			data: `{
+				"engines": {"node": ">=20"},
+				"dependencies": {"react": "^18.2.0", "left-pad": "1.3.0", "typescript": "~5.3.0"},
+				"devDependencies": {"jest": "^29.7.0", "typescript": "^5.0.0"}
+			}`,
+			want: []Requirement{
+				{Docset: "javascript", Package: "javascript"},
+				{Docset: "node", Package: "node", Version: ">=20"},
+				{Docset: "jest", Package: "jest", Version: "^29.7.0"},
+				{Docset: "react", Package: "react", Version: "^18.2.0"},
+				{Docset: "typescript", Package: "typescript", Version: "~5.3.0"},
+			},
+		},
+		{
+			name:  "Gemfile.lock",
+			parse: parseGemfileLock,
+			data: `GEM
+  remote: https://rubygems.org/
+  specs:
+    activesupport (7.1.3)
+      minitest (>= 5.1)
+    nokogiri (1.16.0-x86_64-linux)
+      racc (~> 1.4)
+    rails (7.1.3)
+      activesupport (= 7.1.3)
+    rspec-core (3.12.2)
+
+PLATFORMS
+  x86_64-linux
+
+DEPENDENCIES
+  minitest (~> 5.0)
+  rails (~> 7.1)
+
+RUBY VERSION
+   ruby 3.3.0p0
+
+BUNDLED WITH
+   2.5.3
+`,
+			want: []Requirement{
+				{Docset: "ruby", Package: "ruby", Version: "3.3.0p0"},
+				{Docset: "nokogiri", Package: "nokogiri", Version: "1.16.0-x86_64-linux"},
+				{Docset: "rails", Package: "rails", Version: "7.1.3"},
+				{Docset: "rspec", Package: "rspec-core", Version: "3.12.2"},
+			},
+		},
+		{
+			name:  "pyproject.toml with a multi-line PEP 621 array",
+			parse: parsePyproject,
+			data: `[project]
+name = "app"
+requires-python = ">=3.11"
+dependencies = [
+    "Django>=4.2,<5",
+    "requests",
+    'numpy==1.26.*',  # pinned for pandas
+    "left-pad",
+]
+
+[project.optional-dependencies]
+docs = ["flask"]
+`,
+			want: []Requirement{
+				{Docset: "python", Package: "python", Version: ">=3.11"},
+				{Docset: "django", Package: "django", Version: ">=4.2,<5"},
+				{Docset: "numpy", Package: "numpy", Version: "==1.26.*"},
+				{Docset: "requests", Package: "requests"},
+			},
+		},
+		{
+			name:  "pyproject.toml with a one-line PEP 621 array",
+			parse: parsePyproject,
+			data:  "[project]\ndependencies = [\"flask>=3\", \"click\"]\nversion = \"1.0\"\n",
+			want: []Requirement{
+				{Docset: "python", Package: "python"},
+				{Docset: "click", Package: "click"},
+				{Docset: "flask", Package: "flask", Version: ">=3"},
+			},
+		},
+		{
+			name:  "pyproject.toml for Poetry",
+			parse: parsePyproject,
+			data: `[tool.poetry]
+name = "app"
+version = "0.1.0"
+
+[tool.poetry.dependencies]
+python = "^3.10"
+Django = "^4.2"
+pandas = { version = "^2.1", extras = ["excel"] }
+left-pad = "1.0"
+
+[tool.poetry.group.dev.dependencies]
+numpy = "^1.26"
+`,
+			want: []Requirement{
+				{Docset: "python", Package: "python", Version: "^3.10"},
+				{Docset: "django", Package: "django", Version: "^4.2"},
+				{Docset: "pandas", Package: "pandas", Version: "^2.1"},
+			},
+		},
+		{
+			name:  "Cargo.toml",
+			parse: parseCargoToml,
+			data:  "[package]\nname = \"app\"\nrust-version = \"1.74\"\n\n[dependencies]\nrust-version = \"9\"\n",
+			want:  []Requirement{{Docset: "rust", Package: "rust", Version: "1.74"}},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.parse([]byte(tt.data))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: parsed\n%+v\nwant\n%+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParsePackageJSONMalformed(t *testing.T) {
+	if _, err := parsePackageJSON([]byte(`{"dependencies": [`)); err == nil {
+		t.Error("parsePackageJSON of malformed JSON returned no error")
+	}
+}
+
+func TestBestEntry(t *testing.T) {
+	manifest := model.Manifest{
+		{Slug: "rails~6.1", Version: "6.1"},
+		{Slug: "rails~7.1", Version: "7.1"},
+		{Slug: "rails~7.0", Version: "7.0"},
+		{Slug: "python~3.12", Version: "3.12"},
+		{Slug: "python~3.11", Version: "3.11"},
+		{Slug: "javascript"},
+	}
+
+	tests := []struct {
+		docset, version string
+		want            string // "" for no entry
+	}{
+		{"rails", "7.1.3", "rails~7.1"},
+		{"rails", "~> 7.0", "rails~7.0"},
+		{"rails", "6.1.7.6", "rails~6.1"},
+		{"rails", "8.0.0", "rails~7.1"}, // No match, so the newest
+		{"rails", "", "rails~7.1"},
+		{"rails", "7", "rails~7.1"}, // Too vague to pick a minor version
+		{"python", ">=3.11", "python~3.11"},
+		{"python", "^3.10", "python~3.12"},
+		{"javascript", "", "javascript"},
+		{"django", "4.2", ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if entry := bestEntry(Requirement{Docset: tt.docset, Version: tt.version}, manifest); entry != nil {
+			got = entry.Slug
+		}
+		if got != tt.want {
+			t.Errorf("bestEntry(%s %q) = %q; want %q", tt.docset, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	manifest := model.Manifest{
+		{Slug: "ruby~3.3", Version: "3.3"},
+		{Slug: "rails~7.1", Version: "7.1"},
+	}
+	reqs := []Requirement{
+		{Docset: "ruby", Package: "ruby", Version: "3.3.0p0", Source: "Gemfile.lock"},
+		{Docset: "rails", Package: "rails", Version: "7.1.3", Source: "Gemfile.lock"},
+		{Docset: "rails", Package: "rails", Source: "Gemfile.lock"},
+		{Docset: "nokogiri", Package: "nokogiri", Version: "1.16.0", Source: "Gemfile.lock"},
+	}
+
+	want := []Suggestion{
+		{Slug: "ruby~3.3", Source: "Gemfile.lock", Reason: "ruby 3.3.0p0"},
+		{Slug: "rails~7.1", Source: "Gemfile.lock", Reason: "rails 7.1.3"},
+	}
+	if got := Match(reqs, manifest); !slices.Equal(got, want) {
+		t.Errorf("Match = %+v; want %+v", got, want)
+	}
+}
+
+func TestFindRoot(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "cmd", "app")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module app\n\ngo 1.22\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FindRoot(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := filepath.EvalSymlinks(root); got != root && got != want {
+		t.Errorf("FindRoot(%s) = %q; want %q", sub, got, root)
+	}
+
+	reqs, err := Requirements(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Requirement{{Docset: "go", Package: "go", Version: "1.22", Source: "go.mod"}}; !slices.Equal(reqs, want) {
+		t.Errorf("Requirements = %+v; want %+v", reqs, want)
+	}
+}
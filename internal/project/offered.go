@@ -0,0 +1,50 @@
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// Offered records the directories whose detected docsets were offered for
+// install, so the offer is only made on the first launch in each
+type Offered struct {
+	path string
+	Dirs []string `json:"dirs"`
+}
+
+// LoadOffered reads the record at path; a missing file is an empty record
+func LoadOffered(path string) (*Offered, error) {
+	o := &Offered{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return o, nil
+}
+
+// Has reports whether dir was offered its docsets
+func (o *Offered) Has(dir string) bool {
+	return slices.Contains(o.Dirs, dir)
+}
+
+// Add records dir and saves the record
+func (o *Offered) Add(dir string) error {
+	o.Dirs = append(o.Dirs, dir)
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(o.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", o.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Package project reads .lazydocs.yaml, the file in which a repository
+// declares the docsets it uses
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the project file
+const FileName = ".lazydocs.yaml"
+
+// File is a project file
+type File struct {
+	// Path the file was read from
+	Path string `yaml:"-"`
+
+	// Docsets are DevDocs slugs, with the version where the docset has
+	// several, e.g. "ruby~3.3"
+	Docsets []string `yaml:"docsets"`
+}
+
+// Find looks for a project file in dir and each of its parents. It returns
+// nil when there is none.
+func Find(dir string) (*File, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads a project file, rejecting unknown keys and repeated docsets
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{Path: path}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(f); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i, slug := range f.Docsets {
+		if slug == "" {
+			return nil, fmt.Errorf("%s: docset %d is empty", path, i+1)
+		}
+		if slices.Contains(f.Docsets[:i], slug) {
+			return nil, fmt.Errorf("%s: docset %s is listed twice", path, slug)
+		}
+	}
+	return f, nil
+}
+
+// Dir returns the project's root directory
+func (f *File) Dir() string {
+	return filepath.Dir(f.Path)
+}
+
+// Declares reports whether the file lists a docset
+func (f *File) Declares(slug string) bool {
+	return slices.Contains(f.Docsets, slug)
+}
+
+// Scope returns the installed docsets the file declares, in its order
+func (f *File) Scope(installed []model.Docset) []model.Docset {
+	var scoped []model.Docset
+	for _, slug := range f.Docsets {
+		if i := slices.IndexFunc(installed, func(ds model.Docset) bool { return ds.Slug == slug }); i >= 0 {
+			scoped = append(scoped, installed[i])
+		}
+	}
+	return scoped
+}
+
+// Missing returns the declared docsets that aren't installed
+func (f *File) Missing(installed []model.Docset) []string {
+	var missing []string
+	for _, slug := range f.Docsets {
+		if !slices.ContainsFunc(installed, func(ds model.Docset) bool { return ds.Slug == slug }) {
+			missing = append(missing, slug)
+		}
+	}
+	return missing
+}
+
+// Extra returns the installed docsets the file doesn't declare
+func (f *File) Extra(installed []model.Docset) []model.Docset {
+	var extra []model.Docset
+	for _, ds := range installed {
+		if !f.Declares(ds.Slug) {
+			extra = append(extra, ds)
+		}
+	}
+	return extra
+}
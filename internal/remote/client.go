@@ -0,0 +1,348 @@
+// Package remote is a client for the JSON API of "lazydocs serve", so the
+// TUI and CLI can use an index kept on another machine
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lazydocs/lazydocs/internal/app"
+	"github.com/lazydocs/lazydocs/internal/config"
+	"github.com/lazydocs/lazydocs/internal/data"
+	"github.com/lazydocs/lazydocs/internal/model"
+	"github.com/lazydocs/lazydocs/internal/project"
+)
+
+const (
+	// pingTimeout bounds the reachability check, so an unreachable server
+	// quickly falls back to local docsets
+	pingTimeout = 2 * time.Second
+
+	// requestTimeout bounds every other request
+	requestTimeout = 30 * time.Second
+)
+
+// ErrReadOnly is returned for docset management, which has to be done on
+// the server itself
+var ErrReadOnly = errors.New("docsets can't be installed or removed through a lazydocs server; run the command on the server")
+
+// Client implements app.Backend against a lazydocs server
+type Client struct {
+	base   *url.URL
+	token  string
+	http   *http.Client
+	config config.Config
+}
+
+// New creates a client for the server at baseURL. The token is sent as a
+// bearer token when non-empty; cfg supplies the local display settings.
+func New(baseURL, token string, cfg config.Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q; expected http(s)://host:port", baseURL)
+	}
+	return &Client{
+		base:   base,
+		token:  token,
+		http:   &http.Client{Timeout: requestTimeout},
+		config: cfg,
+	}, nil
+}
+
+// URL returns the server's base URL
+func (c *Client) URL() string {
+	return c.base.String()
+}
+
+// Ping checks that the server is up and accepts the token
+func (c *Client) Ping() error {
+	client := *c
+	client.http = &http.Client{Timeout: pingTimeout}
+	var docsets []model.Docset
+	return client.get("/api/docsets", nil, &docsets)
+}
+
+// Close implements app.Backend; the client holds nothing open
+func (c *Client) Close() error {
+	return nil
+}
+
+// Config returns the local config
+func (c *Client) Config() config.Config {
+	return c.config
+}
+
+// Project returns nil: project files scope local docsets only
+func (c *Client) Project() *project.File {
+	return nil
+}
+
+// ListInstalledDocsets returns the docsets installed on the server
+func (c *Client) ListInstalledDocsets() ([]model.Docset, error) {
+	var docsets []model.Docset
+	err := c.get("/api/docsets", nil, &docsets)
+	return docsets, err
+}
+
+// ScopedDocsets returns every docset installed on the server
+func (c *Client) ScopedDocsets() ([]model.Docset, error) {
+	return c.ListInstalledDocsets()
+}
+
+// Search performs a full-text search on the server
+func (c *Client) Search(query, docset, version string, limit, offset int) ([]model.SearchResult, error) {
+	var resp struct {
+		Results []model.SearchResult `json:"results"`
+	}
+	err := c.get("/api/search", searchParams(query, docset, version, limit, offset), &resp)
+	return resp.Results, err
+}
+
+// CountResults returns the total number of matches for a search
+func (c *Client) CountResults(query, docset, version string) (int, error) {
+	var resp struct {
+		Total int `json:"total"`
+	}
+	err := c.get("/api/search", searchParams(query, docset, version, 1, 0), &resp)
+	return resp.Total, err
+}
+
+// SearchExamples searches code examples on the server
+func (c *Client) SearchExamples(query, docset, version string, limit, offset int) ([]model.Example, error) {
+	var resp struct {
+		Examples []model.Example `json:"examples"`
+	}
+	err := c.get("/api/examples", searchParams(query, docset, version, limit, offset), &resp)
+	return resp.Examples, err
+}
+
+// CountExamples returns the total number of code examples matching a search
+func (c *Client) CountExamples(query, docset, version string) (int, error) {
+	var resp struct {
+		Total int `json:"total"`
+	}
+	err := c.get("/api/examples", searchParams(query, docset, version, 1, 0), &resp)
+	return resp.Total, err
+}
+
+// Suggest returns the server's respelling of a query
+func (c *Client) Suggest(query, docset, version string) (string, error) {
+	var resp struct {
+		Suggestion string `json:"suggestion"`
+	}
+	params := url.Values{"q": {query}}
+	setDocset(params, docset, version)
+	err := c.get("/api/suggest", params, &resp)
+	return resp.Suggestion, err
+}
+
+// FuzzySearch matches query fzf-style against the symbols of the given
+// docsets on the server
+func (c *Client) FuzzySearch(query string, docsets []model.Docset, limit int) ([]model.FuzzyResult, error) {
+	if query == "" || len(docsets) == 0 {
+		return nil, nil
+	}
+	params := url.Values{"q": {query}}
+	setLimit(params, limit)
+	for _, ds := range docsets {
+		params.Add("docset", ds.Slug)
+	}
+
+	var results []model.FuzzyResult
+	err := c.get("/api/fuzzy", params, &results)
+	return results, err
+}
+
+// Related returns entries similar to the given one
+func (c *Client) Related(entry model.Entry, allDocsets bool, limit int) ([]model.SearchResult, error) {
+	params := url.Values{
+		"docset": {slug(entry.Docset, entry.Version)},
+		"path":   {entry.Path},
+		"all":    {strconv.FormatBool(allDocsets)},
+	}
+	setLimit(params, limit)
+	var results []model.SearchResult
+	err := c.get("/api/related", params, &results)
+	return results, err
+}
+
+// ListEntries returns a page of a docset's entries after the given one
+func (c *Client) ListEntries(docset, version string, after *model.Entry, limit int) ([]model.Entry, error) {
+	params := url.Values{}
+	setLimit(params, limit)
+	if after != nil {
+		params.Set("after", after.Cursor())
+	}
+	var resp struct {
+		Entries []model.Entry `json:"entries"`
+	}
+	err := c.get(c.entriesPath(docset, version), params, &resp)
+	return resp.Entries, err
+}
+
+// CountEntries returns the number of entries in a docset, 0 when the
+// server doesn't have it
+func (c *Client) CountEntries(docset, version string) (int, error) {
+	var resp struct {
+		Total int `json:"total"`
+	}
+	err := c.get(c.entriesPath(docset, version), url.Values{"limit": {"1"}}, &resp)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		return 0, nil
+	}
+	return resp.Total, err
+}
+
+// GetEntry returns an entry with its content
+func (c *Client) GetEntry(docset, version, path string) (*model.Entry, error) {
+	var entry model.Entry
+	if err := c.get(c.entriesPath(docset, version)+"/"+(&url.URL{Path: path}).EscapedPath(), nil, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Headings returns the outline of an entry
+func (c *Client) Headings(entryID int64) ([]model.Heading, error) {
+	var headings []model.Heading
+	err := c.get("/api/headings", url.Values{"id": {strconv.FormatInt(entryID, 10)}}, &headings)
+	return headings, err
+}
+
+// CompleteSymbols returns up to limit symbols of a docset on the server
+// starting with prefix
+func (c *Client) CompleteSymbols(slug, prefix string, limit int) ([]string, error) {
+	params := url.Values{"docset": {slug}, "prefix": {prefix}}
+	setLimit(params, limit)
+	var symbols []string
+	err := c.get("/api/symbols", params, &symbols)
+	return symbols, err
+}
+
+// ResolveEntry finds the entries of a docset matching ref, a path or symbol
+func (c *Client) ResolveEntry(slug, ref string) ([]model.Entry, error) {
+	var entries []model.Entry
+	err := c.get("/api/resolve", url.Values{"docset": {slug}, "ref": {ref}}, &entries)
+	return entries, err
+}
+
+// CompareEntry loads the entry for ref from two docsets
+func (c *Client) CompareEntry(slugA, slugB, ref string) (*model.Entry, *model.Entry, error) {
+	var resp struct {
+		From *model.Entry `json:"from"`
+		To   *model.Entry `json:"to"`
+	}
+	err := c.get("/api/compare", url.Values{"from": {slugA}, "to": {slugB}, "ref": {ref}}, &resp)
+	return resp.From, resp.To, err
+}
+
+// ListAvailableDocsets returns ErrReadOnly
+func (c *Client) ListAvailableDocsets(forceRefresh bool) (model.Manifest, error) {
+	return nil, ErrReadOnly
+}
+
+// InstallDocset returns ErrReadOnly
+func (c *Client) InstallDocset(slug string, progress data.ProgressCallback) error {
+	return ErrReadOnly
+}
+
+// RemoveDocset returns ErrReadOnly
+func (c *Client) RemoveDocset(slug string) error {
+	return ErrReadOnly
+}
+
+// OfferDocsets returns nothing; there is nothing the client could install
+func (c *Client) OfferDocsets(dir string) ([]project.Suggestion, error) {
+	return nil, nil
+}
+
+// MarkOffered does nothing, as nothing is offered
+func (c *Client) MarkOffered(dir string) error {
+	return nil
+}
+
+// Error is an error response from the server
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("server: %s", e.Message)
+}
+
+// get fetches an API path, already escaped, and decodes its JSON into v
+func (c *Client) get(path string, params url.Values, v any) error {
+	u := c.base.String() + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach lazydocs server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&body) != nil || body.Error == "" {
+			body.Error = resp.Status
+		}
+		return &Error{Status: resp.StatusCode, Message: body.Error}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode server response: %w", err)
+	}
+	return nil
+}
+
+// entriesPath returns the API path of a docset's entries
+func (c *Client) entriesPath(docset, version string) string {
+	return "/api/docsets/" + url.PathEscape(slug(docset, version)) + "/entries"
+}
+
+func searchParams(query, docset, version string, limit, offset int) url.Values {
+	params := url.Values{"q": {query}, "offset": {strconv.Itoa(offset)}}
+	setLimit(params, limit)
+	setDocset(params, docset, version)
+	return params
+}
+
+// setDocset passes a docset filter on. A version on its own goes as
+// "~<version>", which the server reads as any docset at that version.
+func setDocset(params url.Values, docset, version string) {
+	if docset != "" || version != "" {
+		params.Set("docset", slug(docset, version))
+	}
+}
+
+// setLimit passes a page size on, capped at the most the server accepts;
+// zero or less leaves the server's default
+func setLimit(params url.Values, limit int) {
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(min(limit, app.MaxLimit)))
+	}
+}
+
+func slug(docset, version string) string {
+	return model.Docset{Name: docset, Version: version}.FullSlug()
+}
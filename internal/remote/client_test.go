@@ -0,0 +1,94 @@
+package remote
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lazydocs/lazydocs/internal/app"
+	"github.com/lazydocs/lazydocs/internal/config"
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+func TestDocsetFilter(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.Path+" "+r.URL.Query().Get("docset"))
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, "", config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		docset, version string
+		want            string
+	}{
+		{"", "", ""},
+		{"ruby", "", "ruby"},
+		{"ruby", "3.3", "ruby~3.3"},
+		{"", "3.3", "~3.3"},
+	}
+	for _, tt := range tests {
+		got = nil
+		c.Search("map", tt.docset, tt.version, 10, 0)
+		c.CountResults("map", tt.docset, tt.version)
+		c.SearchExamples("map", tt.docset, tt.version, 10, 0)
+		c.CountExamples("map", tt.docset, tt.version)
+		c.Suggest("map", tt.docset, tt.version)
+
+		want := []string{
+			"/api/search " + tt.want,
+			"/api/search " + tt.want,
+			"/api/examples " + tt.want,
+			"/api/examples " + tt.want,
+			"/api/suggest " + tt.want,
+		}
+		if len(got) != len(want) {
+			t.Fatalf("(%q, %q): requests %q; want %q", tt.docset, tt.version, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("(%q, %q): request %q; want %q", tt.docset, tt.version, got[i], want[i])
+			}
+		}
+
+		// The server splits the slug back apart
+		if name, version := model.ParseSlug(tt.want); name != tt.docset || version != tt.version {
+			t.Errorf("ParseSlug(%q) = %q, %q; want %q, %q", tt.want, name, version, tt.docset, tt.version)
+		}
+	}
+}
+
+func TestPageParams(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.Query().Get("limit")+" "+r.URL.Query().Get("after"))
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, "", config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := model.Entry{ID: 42, Symbol: "map"}
+	c.ListEntries("js", "", nil, 0)
+	c.ListEntries("js", "", &after, 100)
+	c.ListEntries("js", "", &after, app.MaxLimit+500)
+
+	want := []string{" ", "100 " + after.Cursor(), fmt.Sprintf("%d %s", app.MaxLimit, after.Cursor())}
+	if len(got) != len(want) {
+		t.Fatalf("requests %q; want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("request %d: limit and after %q; want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Package render turns entry Markdown into styled terminal text, shared by
+// the TUI preview and the show command, and into HTML for the web UI.
+package render
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// DefaultTheme is used when no theme is configured
+const DefaultTheme = "dark"
+
+// Markdown renders md with the glamour style named by theme, word-wrapped at
+// width. On failure it returns md unchanged along with the error, so callers
+// can always show something.
+func Markdown(md, theme string, width int) (string, error) {
+	if theme == "" {
+		theme = DefaultTheme
+	}
+
+	renderer, err := glamour.NewTermRenderer(
+		glamour.WithStylePath(theme),
+		glamour.WithWordWrap(width),
+	)
+	if err != nil {
+		return md, fmt.Errorf("failed to create renderer: %w", err)
+	}
+
+	rendered, err := renderer.Render(md)
+	if err != nil {
+		return md, fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return rendered, nil
+}
+
+// html converts Markdown with GitHub's extensions (tables, strikethrough,
+// autolinks). Raw HTML in the Markdown is dropped.
+var html = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// HTML renders md as an HTML fragment
+func HTML(md string) (string, error) {
+	var buf bytes.Buffer
+	if err := html.Convert([]byte(md), &buf); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,320 @@
+package rpc
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/app"
+	"github.com/lazydocs/lazydocs/internal/db"
+	"github.com/lazydocs/lazydocs/internal/model"
+	"github.com/lazydocs/lazydocs/internal/remote"
+	"github.com/lazydocs/lazydocs/internal/render"
+)
+
+const (
+	// defaultLimit is the number of search results when none is given;
+	// the most is app.MaxLimit
+	defaultLimit = 50
+
+	// defaultWidth wraps rendered entries when the client gives no width
+	defaultWidth = 80
+)
+
+type searchParams struct {
+	Query  string `json:"query"`
+	Docset string `json:"docset"` // Optional slug, e.g. "ruby~3.3"
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+type searchResult struct {
+	Query   string               `json:"query"`
+	Total   int                  `json:"total"`
+	Results []model.SearchResult `json:"results"`
+}
+
+type entryParams struct {
+	Docset string `json:"docset"`
+	Path   string `json:"path"`
+	Format string `json:"format"` // "markdown" (default) or "ansi"
+	Width  int    `json:"width"`  // Wrap width for "ansi"
+}
+
+type docsetsParams struct {
+	Available bool `json:"available"` // List every docset there is to install
+	Refresh   bool `json:"refresh"`   // Refetch the list of available docsets
+}
+
+type installParams struct {
+	Docset string `json:"docset"`
+}
+
+// progress is the params of a "progress" notification
+type progress struct {
+	Docset     string `json:"docset"`
+	Status     string `json:"status"`
+	Downloaded int64  `json:"downloaded"`
+	Total      int64  `json:"total"`
+}
+
+type linkParams struct {
+	Docset string `json:"docset"` // Docset of the entry the link is in
+	From   string `json:"from"`   // Path of that entry
+	Href   string `json:"href"`   // Link target as written in its Markdown
+}
+
+// linkTarget is where a link leads: an entry, or a URL outside the docs
+type linkTarget struct {
+	Entry    *model.Entry `json:"entry,omitempty"`
+	Fragment string       `json:"fragment,omitempty"` // Anchor within the entry
+	URL      string       `json:"url,omitempty"`
+}
+
+func (s *Server) search(raw json.RawMessage, notify func(string, any)) (any, error) {
+	var p searchParams
+	if err := decodeParams(raw, &p); err != nil {
+		return nil, err
+	}
+	if p.Query == "" {
+		return nil, invalidParams("query is required")
+	}
+	if p.Limit == 0 {
+		p.Limit = defaultLimit
+	}
+	if p.Limit < 1 || p.Limit > app.MaxLimit || p.Offset < 0 {
+		return nil, invalidParams(fmt.Sprintf("limit must be from 1 to %d and offset from 0", app.MaxLimit))
+	}
+	name, version := model.ParseSlug(p.Docset)
+
+	results, err := s.app.Search(p.Query, name, version, p.Limit, p.Offset)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.app.CountResults(p.Query, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = []model.SearchResult{}
+	}
+	return searchResult{Query: p.Query, Total: total, Results: results}, nil
+}
+
+func (s *Server) getEntry(raw json.RawMessage, notify func(string, any)) (any, error) {
+	var p entryParams
+	if err := decodeParams(raw, &p); err != nil {
+		return nil, err
+	}
+	if p.Docset == "" || p.Path == "" {
+		return nil, invalidParams("docset and path are required")
+	}
+	if p.Width == 0 {
+		p.Width = defaultWidth
+	}
+
+	name, version := model.ParseSlug(p.Docset)
+	entry, err := s.app.GetEntry(name, version, p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.Format {
+	case "", "markdown":
+	case "ansi":
+		if p.Width < 1 {
+			return nil, invalidParams("width must be positive")
+		}
+		// Rendering falls back to the Markdown, which is still worth showing
+		entry.Content, _ = render.Markdown(entry.Content, s.app.Config().Theme, p.Width)
+	default:
+		return nil, invalidParams(fmt.Sprintf("unknown format %q; expected markdown or ansi", p.Format))
+	}
+	return entry, nil
+}
+
+func (s *Server) listDocsets(raw json.RawMessage, notify func(string, any)) (any, error) {
+	var p docsetsParams
+	if err := decodeParams(raw, &p); err != nil {
+		return nil, err
+	}
+
+	if p.Available {
+		manifest, err := s.app.ListAvailableDocsets(p.Refresh)
+		if err != nil {
+			return nil, err
+		}
+		if manifest == nil {
+			manifest = model.Manifest{}
+		}
+		return manifest, nil
+	}
+
+	docsets, err := s.app.ScopedDocsets()
+	if err != nil {
+		return nil, err
+	}
+	if docsets == nil {
+		docsets = []model.Docset{}
+	}
+	return docsets, nil
+}
+
+func (s *Server) install(raw json.RawMessage, notify func(string, any)) (any, error) {
+	var p installParams
+	if err := decodeParams(raw, &p); err != nil {
+		return nil, err
+	}
+	if p.Docset == "" {
+		return nil, invalidParams("docset is required")
+	}
+
+	s.installing.Lock()
+	defer s.installing.Unlock()
+
+	// Like "install --json", only whole-percent steps are worth a message
+	lastStatus, lastPct := "", -1.0
+	err := s.app.InstallDocset(p.Docset, func(downloaded, total int64, status string) {
+		pct := -1.0
+		if total > 0 {
+			pct = math.Floor(float64(downloaded) / float64(total) * 100)
+		}
+		if status == lastStatus && pct == lastPct {
+			return
+		}
+		lastStatus, lastPct = status, pct
+		notify("progress", progress{Docset: p.Docset, Status: status, Downloaded: downloaded, Total: total})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	docsets, err := s.app.ListInstalledDocsets()
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range docsets {
+		if ds.Slug == p.Docset {
+			return ds, nil
+		}
+	}
+	return nil, fmt.Errorf("docset %s is not in the index after installing", p.Docset)
+}
+
+func (s *Server) resolveLink(raw json.RawMessage, notify func(string, any)) (any, error) {
+	var p linkParams
+	if err := decodeParams(raw, &p); err != nil {
+		return nil, err
+	}
+	if p.Docset == "" || p.Href == "" {
+		return nil, invalidParams("docset and href are required")
+	}
+
+	u, err := url.Parse(p.Href)
+	if err != nil {
+		return nil, invalidParams(fmt.Sprintf("malformed href %q", p.Href))
+	}
+	if u.Scheme != "" || u.Host != "" {
+		return linkTarget{URL: p.Href}, nil
+	}
+
+	// DevDocs links are relative to the entry's directory, except those
+	// starting with "/", which name the docset first
+	slug, target := p.Docset, u.Path
+	switch {
+	case target == "":
+		target = p.From
+	case strings.HasPrefix(target, "/"):
+		slug, target, _ = strings.Cut(strings.TrimPrefix(target, "/"), "/")
+	default:
+		target = path.Join(path.Dir(p.From), target)
+	}
+
+	entry, err := s.findLinked(slug, target, u.Fragment)
+	if err != nil {
+		return nil, err
+	}
+	return linkTarget{Entry: entry, Fragment: u.Fragment}, nil
+}
+
+// findLinked loads the entry a link leads to. DevDocs indexes some entries
+// under "page#anchor", so that is tried before the page itself and, last,
+// a symbol of that name.
+func (s *Server) findLinked(slug, target, fragment string) (*model.Entry, error) {
+	name, version := model.ParseSlug(slug)
+	if fragment != "" {
+		entry, err := s.app.GetEntry(name, version, target+"#"+fragment)
+		if !isNotFound(err) {
+			return withoutContent(entry), err
+		}
+	}
+	entry, err := s.app.GetEntry(name, version, target)
+	if !isNotFound(err) {
+		return withoutContent(entry), err
+	}
+
+	matches, err := s.app.ResolveEntry(slug, target)
+	if errors.Is(err, app.ErrNoEntry) || isNotFound(err) {
+		return nil, &Error{Code: codeNotFound, Message: fmt.Sprintf("no entry for link %s in %s", target, slug)}
+	}
+	if err != nil {
+		return nil, toError(err)
+	}
+	return withoutContent(&matches[0]), nil
+}
+
+// withoutContent drops an entry's content; clients fetch it with getEntry
+func withoutContent(e *model.Entry) *model.Entry {
+	if e != nil {
+		e.Content = ""
+	}
+	return e
+}
+
+// decodeParams unmarshals params into v; missing params leave v as is
+func decodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return invalidParams("invalid params: " + err.Error())
+	}
+	return nil
+}
+
+func invalidParams(msg string) *Error {
+	return &Error{Code: codeInvalidParams, Message: msg}
+}
+
+// toError turns a method's error into a JSON-RPC error, telling missing
+// entries and malformed queries apart from failures
+func toError(err error) *Error {
+	var rpcErr *Error
+	var queryErr *db.QueryError
+	var remoteErr *remote.Error
+	switch {
+	case errors.As(err, &rpcErr):
+		return rpcErr
+	case isNotFound(err):
+		return &Error{Code: codeNotFound, Message: "no such entry"}
+	case errors.As(err, &queryErr):
+		return invalidParams(err.Error())
+	case errors.As(err, &remoteErr) && remoteErr.Status == http.StatusBadRequest:
+		return invalidParams(err.Error())
+	}
+	return &Error{Code: codeFailed, Message: err.Error()}
+}
+
+// isNotFound reports whether the index, local or on a lazydocs server, has
+// no such entry
+func isNotFound(err error) bool {
+	var remoteErr *remote.Error
+	return errors.Is(err, sql.ErrNoRows) ||
+		(errors.As(err, &remoteErr) && remoteErr.Status == http.StatusNotFound)
+}
@@ -0,0 +1,130 @@
+//go:build sqlite_fts5
+
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lazydocs/lazydocs/internal/app"
+	"github.com/lazydocs/lazydocs/internal/config"
+	"github.com/lazydocs/lazydocs/internal/data"
+	"github.com/lazydocs/lazydocs/internal/db"
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+// lockingBackend installs by holding the index's write lock until release
+// is closed, as indexing a large docset does
+type lockingBackend struct {
+	app.Backend
+	dbPath  string
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *lockingBackend) InstallDocset(slug string, progress data.ProgressCallback) error {
+	database, err := db.Open(b.dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	conn, err := database.Conn().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN EXCLUSIVE"); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "UPDATE docsets SET mtime = mtime + 1"); err != nil {
+		return err
+	}
+	close(b.started)
+	<-b.release
+	_, err = conn.ExecContext(ctx, "COMMIT")
+	return err
+}
+
+func TestSearchDuringInstall(t *testing.T) {
+	dir := t.TempDir()
+	paths := config.NewPaths(dir, filepath.Join(dir, "config.yaml"))
+
+	database, err := db.Open(paths.DBPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	docset := model.Docset{Slug: "js", Name: "js", DisplayName: "JavaScript"}
+	entries := []model.Entry{{Docset: "js", Symbol: "Array.prototype.map", Title: "map", Path: "array/map", Content: "# map"}}
+	if err := db.NewIndexer(database).IndexDocset(docset, entries); err != nil {
+		t.Fatalf("IndexDocset: %v", err)
+	}
+	database.Close()
+
+	application, err := app.NewWithPaths(paths)
+	if err != nil {
+		t.Fatalf("NewWithPaths: %v", err)
+	}
+	defer application.Close()
+
+	backend := &lockingBackend{
+		Backend: application,
+		dbPath:  paths.DBPath,
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- New(backend).Serve(inR, outW)
+		outW.Close()
+	}()
+
+	replies := make(chan response)
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			var resp response
+			if err := json.Unmarshal(scanner.Bytes(), &resp); err == nil && resp.ID != nil {
+				replies <- resp
+			}
+		}
+		close(replies)
+	}()
+
+	io.WriteString(inW, `{"jsonrpc": "2.0", "id": 1, "method": "install", "params": {"docset": "js"}}`+"\n")
+	select {
+	case <-backend.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("install didn't start")
+	}
+
+	io.WriteString(inW, `{"jsonrpc": "2.0", "id": 2, "method": "search", "params": {"query": "map"}}`+"\n")
+	select {
+	case resp := <-replies:
+		if string(resp.ID) != "2" || resp.Error != nil {
+			t.Fatalf("got %s %+v while installing; want the search results", resp.ID, resp.Error)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("search waited for the install")
+	}
+
+	close(backend.release)
+	if resp := <-replies; string(resp.ID) != "1" || resp.Error != nil {
+		t.Errorf("install: got %s %+v", resp.ID, resp.Error)
+	}
+
+	inW.Close()
+	if err := <-done; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+}
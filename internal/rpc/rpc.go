@@ -0,0 +1,304 @@
+// Package rpc serves the index to editors as JSON-RPC 2.0 over a pair of
+// streams, usually the stdin and stdout of "lazydocs rpc". One process
+// answers every request, so editors don't start one per keystroke.
+//
+// Messages are either one JSON value per line or, as in LSP, framed with a
+// "Content-Length" header; the first message decides which, and responses
+// use the same framing. Requests are answered concurrently, so a long
+// install doesn't hold up searches, and batches are supported.
+//
+// Methods:
+//
+//	search       {query, docset?, limit?, offset?} -> {query, total, results}
+//	getEntry     {docset, path, format?, width?}   -> entry; format "markdown"
+//	                                                  (default) or "ansi"
+//	listDocsets  {available?, refresh?}            -> installed docsets, or
+//	                                                  every docset to install
+//	install      {docset}                          -> installed docset; sends
+//	                                                  "progress" notifications
+//	resolveLink  {docset, from, href}              -> {entry, fragment} or {url}
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+
+	"github.com/lazydocs/lazydocs/internal/app"
+)
+
+// JSON-RPC error codes; codeFailed and codeNotFound are lazydocs' own
+const (
+	codeParse          = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeFailed         = -32000
+	codeNotFound       = -32001
+)
+
+// maxMessage bounds a single incoming message
+const maxMessage = 16 << 20
+
+// Error is a JSON-RPC error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// request is an incoming request or, without an id, a notification
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response answers a request with either a result or an error
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// MarshalJSON writes "result" on every success, even a null, false or
+// empty one, as JSON-RPC requires
+func (r *response) MarshalJSON() ([]byte, error) {
+	if r.Error != nil {
+		return json.Marshal(struct {
+			JSONRPC string          `json:"jsonrpc"`
+			ID      json.RawMessage `json:"id"`
+			Error   *Error          `json:"error"`
+		}{r.JSONRPC, r.ID, r.Error})
+	}
+	return json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  any             `json:"result"`
+	}{r.JSONRPC, r.ID, r.Result})
+}
+
+// notification is a message from the server that expects no answer
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// handler runs a method. notify sends a notification to the client.
+type handler func(params json.RawMessage, notify func(method string, params any)) (any, error)
+
+// Server answers JSON-RPC requests from one client
+type Server struct {
+	app     app.Backend
+	methods map[string]handler
+
+	installing sync.Mutex // Installs run one at a time
+
+	out     *bufio.Writer
+	outMu   sync.Mutex
+	headers bool // Frame messages with Content-Length
+}
+
+// New creates a Server for a backend
+func New(backend app.Backend) *Server {
+	s := &Server{app: backend}
+	s.methods = map[string]handler{
+		"search":      s.search,
+		"getEntry":    s.getEntry,
+		"listDocsets": s.listDocsets,
+		"install":     s.install,
+		"resolveLink": s.resolveLink,
+	}
+	return s
+}
+
+// Serve reads requests from r and writes responses to w until r ends,
+// then waits for the requests still running
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	in := bufio.NewReader(r)
+	s.out = bufio.NewWriter(w)
+
+	first, err := firstByte(in)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.headers = first != '{' && first != '['
+
+	var running sync.WaitGroup
+	defer running.Wait()
+	for {
+		msg, err := s.read(in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read request: %w", err)
+		}
+		if len(msg) == 0 {
+			continue
+		}
+
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			if reply := s.handle(msg); reply != nil {
+				s.write(reply)
+			}
+		}()
+	}
+}
+
+// handle answers a single message or a batch; nil means nothing to send
+func (s *Server) handle(msg []byte) any {
+	if msg[0] != '[' {
+		if resp := s.call(msg); resp != nil {
+			return resp
+		}
+		return nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(msg, &batch); err != nil {
+		return errorResponse(nil, codeParse, "parse error: "+err.Error())
+	}
+	if len(batch) == 0 {
+		return errorResponse(nil, codeInvalidRequest, "empty batch")
+	}
+	var replies []*response
+	for _, m := range batch {
+		if resp := s.call(m); resp != nil {
+			replies = append(replies, resp)
+		}
+	}
+	if len(replies) == 0 {
+		return nil
+	}
+	return replies
+}
+
+// call runs one request; notifications get no response
+func (s *Server) call(msg []byte) *response {
+	var req request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			return errorResponse(nil, codeParse, "parse error: "+err.Error())
+		}
+		return errorResponse(nil, codeInvalidRequest, "invalid request: "+err.Error())
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, codeInvalidRequest, `invalid request: expected "jsonrpc": "2.0" and a method`)
+	}
+
+	method, ok := s.methods[req.Method]
+	if !ok {
+		if req.ID == nil {
+			return nil
+		}
+		return errorResponse(req.ID, codeMethodNotFound, fmt.Sprintf("method not found: %s", req.Method))
+	}
+
+	result, err := method(req.Params, func(method string, params any) {
+		s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+	})
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		rpcErr := toError(err)
+		return errorResponse(req.ID, rpcErr.Code, rpcErr.Message)
+	}
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func errorResponse(id json.RawMessage, code int, msg string) *response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &response{JSONRPC: "2.0", ID: id, Error: &Error{Code: code, Message: msg}}
+}
+
+// read returns the next message in the stream's framing
+func (s *Server) read(in *bufio.Reader) ([]byte, error) {
+	if !s.headers {
+		// Read a buffer at a time, so a line that never ends can't take
+		// more than maxMessage
+		var line []byte
+		for {
+			chunk, err := in.ReadSlice('\n')
+			if len(line)+len(chunk) > maxMessage {
+				return nil, fmt.Errorf("message exceeds %d bytes", maxMessage)
+			}
+			line = append(line, chunk...)
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+			if err == io.EOF && len(bytes.TrimSpace(line)) > 0 {
+				err = nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return bytes.TrimSpace(line), nil
+		}
+	}
+
+	header, err := textproto.NewReader(in).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 || length > maxMessage {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(in, msg); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(msg), nil
+}
+
+// write sends a message, never interleaved with another
+func (s *Server) write(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(errorResponse(nil, codeFailed, "failed to encode response: "+err.Error()))
+	}
+
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if s.headers {
+		fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(data))
+		s.out.Write(data)
+	} else {
+		s.out.Write(data)
+		s.out.WriteByte('\n')
+	}
+	s.out.Flush()
+}
+
+// firstByte peeks past leading whitespace at the first byte of the stream
+func firstByte(in *bufio.Reader) (byte, error) {
+	for {
+		b, err := in.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, in.UnreadByte()
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package rpc
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lazydocs/lazydocs/internal/app"
+	"github.com/lazydocs/lazydocs/internal/model"
+	"github.com/lazydocs/lazydocs/internal/remote"
+)
+
+func TestResponseAlwaysHasResult(t *testing.T) {
+	tests := []struct {
+		resp *response
+		want string
+	}{
+		{&response{JSONRPC: "2.0", ID: json.RawMessage("1")}, `{"jsonrpc":"2.0","id":1,"result":null}`},
+		{&response{JSONRPC: "2.0", ID: json.RawMessage("2"), Result: false}, `{"jsonrpc":"2.0","id":2,"result":false}`},
+		{&response{JSONRPC: "2.0", ID: json.RawMessage("3"), Result: []string{}}, `{"jsonrpc":"2.0","id":3,"result":[]}`},
+		{errorResponse(json.RawMessage("4"), codeFailed, "boom"), `{"jsonrpc":"2.0","id":4,"error":{"code":-32000,"message":"boom"}}`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("got %s; want %s", data, tt.want)
+		}
+	}
+
+	// Batches hold pointers too
+	data, _ := json.Marshal([]*response{{JSONRPC: "2.0", ID: json.RawMessage("5")}})
+	if string(data) != `[{"jsonrpc":"2.0","id":5,"result":null}]` {
+		t.Errorf("batch: got %s", data)
+	}
+}
+
+func TestOverlongLine(t *testing.T) {
+	in := io.MultiReader(strings.NewReader("{"), strings.NewReader(strings.Repeat(" ", maxMessage+1)))
+	var out bytes.Buffer
+	err := New(nil).Serve(in, &out)
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("Serve of an endless line = %v; want a size error", err)
+	}
+}
+
+func TestLineFraming(t *testing.T) {
+	var out bytes.Buffer
+	in := strings.NewReader(`{"jsonrpc": "2.0", "id": 1, "method": "nope"}` + "\n" + `{"jsonrpc": "2.0", "id": 2, "method": "nope"}`)
+	if err := New(nil).Serve(in, &out); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out.String(), `"code":-32601`); n != 2 {
+		t.Errorf("got %d method-not-found replies; want 2:\n%s", n, out.String())
+	}
+}
+
+// linkBackend has no entry at any path and resolves symbols with resolve
+type linkBackend struct {
+	app.Backend
+	resolve func() ([]model.Entry, error)
+}
+
+func (b linkBackend) GetEntry(docset, version, path string) (*model.Entry, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (b linkBackend) ResolveEntry(slug, ref string) ([]model.Entry, error) {
+	return b.resolve()
+}
+
+func TestFindLinkedSymbolFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolve  func() ([]model.Entry, error)
+		wantCode int // 0 for success
+	}{
+		{
+			name: "symbol",
+			resolve: func() ([]model.Entry, error) {
+				return []model.Entry{{Symbol: "map", Path: "array/map", Content: "# map"}}, nil
+			},
+		},
+		{
+			name:     "no entry",
+			resolve:  func() ([]model.Entry, error) { return nil, fmt.Errorf("%w \"map\" in js", app.ErrNoEntry) },
+			wantCode: codeNotFound,
+		},
+		{
+			name: "not on the server",
+			resolve: func() ([]model.Entry, error) {
+				return nil, &remote.Error{Status: http.StatusNotFound, Message: "no entry"}
+			},
+			wantCode: codeNotFound,
+		},
+		{
+			name:     "database failure",
+			resolve:  func() ([]model.Entry, error) { return nil, errors.New("sql: database is closed") },
+			wantCode: codeFailed,
+		},
+		{
+			name: "server failure",
+			resolve: func() ([]model.Entry, error) {
+				return nil, &remote.Error{Status: http.StatusBadGateway, Message: "bad gateway"}
+			},
+			wantCode: codeFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		s := New(linkBackend{resolve: tt.resolve})
+		entry, err := s.findLinked("js", "map", "")
+
+		if tt.wantCode == 0 {
+			if err != nil {
+				t.Errorf("%s: findLinked: %v", tt.name, err)
+			} else if entry.Path != "array/map" || entry.Content != "" {
+				t.Errorf("%s: findLinked = %+v; want array/map without content", tt.name, entry)
+			}
+			continue
+		}
+		if code := toError(err).Code; code != tt.wantCode {
+			t.Errorf("%s: findLinked error %v has code %d; want %d", tt.name, err, code, tt.wantCode)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/lazydocs/lazydocs/internal/app"
+	"github.com/lazydocs/lazydocs/internal/db"
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+// defaultLimit is the page size of list endpoints when none is given; the
+// most is app.MaxLimit
+const defaultLimit = 50
+
+// searchResponse is the body of /api/search
+type searchResponse struct {
+	Query   string               `json:"query"`
+	Total   int                  `json:"total"`
+	Results []model.SearchResult `json:"results"`
+}
+
+// entriesResponse is the body of /api/docsets/{slug}/entries
+type entriesResponse struct {
+	Total   int           `json:"total"`
+	Entries []model.Entry `json:"entries"`
+	Next    string        `json:"next,omitempty"` // Cursor for the next page, if any
+}
+
+// examplesResponse is the body of /api/examples
+type examplesResponse struct {
+	Query    string          `json:"query"`
+	Total    int             `json:"total"`
+	Examples []model.Example `json:"examples"`
+}
+
+// compareResponse is the body of /api/compare
+type compareResponse struct {
+	From *model.Entry `json:"from"`
+	To   *model.Entry `json:"to"`
+}
+
+func (s *Server) apiDocsets(w http.ResponseWriter, r *http.Request) {
+	docsets, err := s.app.ListInstalledDocsets()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if docsets == nil {
+		docsets = []model.Docset{}
+	}
+	writeJSON(w, docsets)
+}
+
+func (s *Server) apiSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := q.Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "missing q parameter")
+		return
+	}
+	limit, offset, err := pageParams(q.Get("limit"), q.Get("offset"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	name, version := model.ParseSlug(q.Get("docset"))
+
+	results, err := s.app.Search(query, name, version, limit, offset)
+	if err == nil && results == nil {
+		results = []model.SearchResult{}
+	}
+	var total int
+	if err == nil {
+		total, err = s.app.CountResults(query, name, version)
+	}
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	writeJSON(w, searchResponse{Query: query, Total: total, Results: results})
+}
+
+func (s *Server) apiExamples(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := q.Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "missing q parameter")
+		return
+	}
+	limit, offset, err := pageParams(q.Get("limit"), q.Get("offset"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	name, version := model.ParseSlug(q.Get("docset"))
+
+	examples, err := s.app.SearchExamples(query, name, version, limit, offset)
+	if err == nil && examples == nil {
+		examples = []model.Example{}
+	}
+	var total int
+	if err == nil {
+		total, err = s.app.CountExamples(query, name, version)
+	}
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	writeJSON(w, examplesResponse{Query: query, Total: total, Examples: examples})
+}
+
+func (s *Server) apiSuggest(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	name, version := model.ParseSlug(q.Get("docset"))
+	suggestion, err := s.app.Suggest(q.Get("q"), name, version)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+	writeJSON(w, map[string]string{"suggestion": suggestion})
+}
+
+func (s *Server) apiFuzzy(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _, err := pageParams(q.Get("limit"), "")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var docsets []model.Docset
+	for _, slug := range q["docset"] {
+		name, version := model.ParseSlug(slug)
+		docsets = append(docsets, model.Docset{Slug: slug, Name: name, Version: version})
+	}
+
+	results, err := s.app.FuzzySearch(q.Get("q"), docsets, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if results == nil {
+		results = []model.FuzzyResult{}
+	}
+	writeJSON(w, results)
+}
+
+func (s *Server) apiRelated(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _, err := pageParams(q.Get("limit"), "")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	name, version := model.ParseSlug(q.Get("docset"))
+	entry, err := s.app.GetEntry(name, version, q.Get("path"))
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "no such entry")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results, err := s.app.Related(*entry, q.Get("all") == "true", limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if results == nil {
+		results = []model.SearchResult{}
+	}
+	writeJSON(w, results)
+}
+
+func (s *Server) apiHeadings(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id must be an entry id")
+		return
+	}
+
+	headings, err := s.app.Headings(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if headings == nil {
+		headings = []model.Heading{}
+	}
+	writeJSON(w, headings)
+}
+
+func (s *Server) apiSymbols(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _, err := pageParams(q.Get("limit"), "")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	symbols, err := s.app.CompleteSymbols(q.Get("docset"), q.Get("prefix"), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if symbols == nil {
+		symbols = []string{}
+	}
+	writeJSON(w, symbols)
+}
+
+func (s *Server) apiResolve(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	entries, err := s.app.ResolveEntry(q.Get("docset"), q.Get("ref"))
+	if err != nil {
+		writeEntryError(w, err)
+		return
+	}
+	writeJSON(w, entries)
+}
+
+func (s *Server) apiCompare(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to, err := s.app.CompareEntry(q.Get("from"), q.Get("to"), q.Get("ref"))
+	if err != nil {
+		writeEntryError(w, err)
+		return
+	}
+	writeJSON(w, compareResponse{From: from, To: to})
+}
+
+func (s *Server) apiEntries(w http.ResponseWriter, r *http.Request) {
+	name, version := model.ParseSlug(r.PathValue("slug"))
+	limit, _, err := pageParams(r.URL.Query().Get("limit"), "")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	after, err := model.ParseCursor(r.URL.Query().Get("after"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	total, err := s.app.CountEntries(name, version)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if total == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("docset %s is not installed", r.PathValue("slug")))
+		return
+	}
+
+	// One entry more than the page tells whether there's a next one
+	entries, err := s.app.ListEntries(name, version, after, limit+1)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := entriesResponse{Total: total, Entries: entries}
+	if resp.Entries == nil {
+		resp.Entries = []model.Entry{}
+	}
+	if len(entries) > limit {
+		resp.Entries = entries[:limit]
+		resp.Next = entries[limit-1].Cursor()
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) apiEntry(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.entry(r)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "no such entry")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, entry)
+}
+
+// entry loads the entry named by the request's slug and path
+func (s *Server) entry(r *http.Request) (*model.Entry, error) {
+	name, version := model.ParseSlug(r.PathValue("slug"))
+	return s.app.GetEntry(name, version, r.PathValue("path"))
+}
+
+// pageParams parses the limit and offset parameters, either of which may
+// be empty
+func pageParams(limitParam, offsetParam string) (limit, offset int, err error) {
+	limit = defaultLimit
+	if limitParam != "" {
+		if limit, err = strconv.Atoi(limitParam); err != nil || limit < 1 || limit > app.MaxLimit {
+			return 0, 0, fmt.Errorf("limit must be a number from 1 to %d", app.MaxLimit)
+		}
+	}
+	if offsetParam != "" {
+		if offset, err = strconv.Atoi(offsetParam); err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be a number from 0")
+		}
+	}
+	return limit, offset, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+// writeQueryError reports a malformed query as the client's mistake and
+// anything else as the server's
+func writeQueryError(w http.ResponseWriter, err error) {
+	var queryErr *db.QueryError
+	if errors.As(err, &queryErr) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+// writeEntryError reports a missing entry as not found and anything else,
+// like a database failure, as the server's fault
+func writeEntryError(w http.ResponseWriter, err error) {
+	if errors.Is(err, app.ErrNoEntry) || errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
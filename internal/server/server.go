@@ -0,0 +1,124 @@
+// Package server serves the index over HTTP: a JSON API under /api for
+// scripts and launchers, and a small HTML UI for browsers.
+//
+// API (all GET, all JSON):
+//
+//	/api/docsets                          Installed docsets
+//	/api/search?q=&docset=&limit=&offset= Search, with the TUI's query syntax
+//	/api/docsets/{slug}/entries?limit=&after=
+//	                                      A page of a docset's entries; pass
+//	                                      the "next" of one page as "after"
+//	/api/docsets/{slug}/entries/{path}    One entry, with its Markdown content
+//	/api/examples?q=&docset=&limit=&offset=
+//	                                      Search code examples only
+//	/api/suggest?q=&docset=               A respelling of the query
+//	/api/fuzzy?q=&docset=&limit=          fzf-style symbol matches; repeat
+//	                                      docset for several
+//	/api/related?docset=&path=&all=&limit=
+//	                                      Entries similar to one
+//	/api/headings?id=                     Outline of an entry
+//	/api/symbols?docset=&prefix=&limit=   Symbols starting with prefix
+//	/api/resolve?docset=&ref=             Entries matching a path or symbol
+//	/api/compare?from=&to=&ref=           An entry in two docsets
+//
+// Errors are {"error": "..."} with a 4xx or 5xx status.
+package server
+
+import (
+	"crypto/subtle"
+	"embed"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/app"
+)
+
+// tokenCookie keeps a browser signed in after it opened a page with
+// ?token=, so the UI's own links work
+const tokenCookie = "lazydocs_token"
+
+//go:embed templates/*.html
+var templates embed.FS
+
+// Server is an http.Handler for the API and the web UI
+type Server struct {
+	app   *app.App
+	token string
+	mux   *http.ServeMux
+	pages *template.Template
+}
+
+// New creates a Server for an app. A non-empty token must be presented as
+// "Authorization: Bearer <token>", or by a browser as ?token=<token> once.
+func New(application *app.App, token string) *Server {
+	s := &Server{
+		app:   application,
+		token: token,
+		mux:   http.NewServeMux(),
+		pages: template.Must(template.New("").Funcs(templateFuncs).ParseFS(templates, "templates/*.html")),
+	}
+
+	s.mux.HandleFunc("GET /api/docsets", s.apiDocsets)
+	s.mux.HandleFunc("GET /api/search", s.apiSearch)
+	s.mux.HandleFunc("GET /api/docsets/{slug}/entries", s.apiEntries)
+	s.mux.HandleFunc("GET /api/docsets/{slug}/entries/{path...}", s.apiEntry)
+	s.mux.HandleFunc("GET /api/examples", s.apiExamples)
+	s.mux.HandleFunc("GET /api/suggest", s.apiSuggest)
+	s.mux.HandleFunc("GET /api/fuzzy", s.apiFuzzy)
+	s.mux.HandleFunc("GET /api/related", s.apiRelated)
+	s.mux.HandleFunc("GET /api/headings", s.apiHeadings)
+	s.mux.HandleFunc("GET /api/symbols", s.apiSymbols)
+	s.mux.HandleFunc("GET /api/resolve", s.apiResolve)
+	s.mux.HandleFunc("GET /api/compare", s.apiCompare)
+	s.mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	})
+
+	s.mux.HandleFunc("GET /{$}", s.pageIndex)
+	s.mux.HandleFunc("GET /search", s.pageSearch)
+	s.mux.HandleFunc("GET /docs/{slug}/{$}", s.pageDocset)
+	s.mux.HandleFunc("GET /docs/{slug}/{path...}", s.pageEntry)
+
+	return s
+}
+
+// ServeHTTP checks the token, then serves the request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" && !s.authorized(w, r) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="lazydocs"`)
+			writeError(w, http.StatusUnauthorized, "missing or wrong bearer token")
+		} else {
+			http.Error(w, "Unauthorized: open this page with ?token=<token>", http.StatusUnauthorized)
+		}
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized reports whether the request carries the token, remembering a
+// ?token= in a cookie for the pages that follow
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return s.tokenMatches(auth)
+	}
+	if c, err := r.Cookie(tokenCookie); err == nil && s.tokenMatches(c.Value) {
+		return true
+	}
+	if t := r.URL.Query().Get("token"); t != "" && s.tokenMatches(t) {
+		http.SetCookie(w, &http.Cookie{
+			Name:     tokenCookie,
+			Value:    t,
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
+		return true
+	}
+	return false
+}
+
+func (s *Server) tokenMatches(t string) bool {
+	return subtle.ConstantTimeCompare([]byte(t), []byte(s.token)) == 1
+}
@@ -0,0 +1,295 @@
+//go:build sqlite_fts5
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lazydocs/lazydocs/internal/app"
+	"github.com/lazydocs/lazydocs/internal/config"
+	"github.com/lazydocs/lazydocs/internal/db"
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+const testToken = "s3cret"
+
+// newTestApp opens an app in a temp dir whose index holds a "js" docset
+// with a few array methods
+func newTestApp(t *testing.T) *app.App {
+	t.Helper()
+	dir := t.TempDir()
+	paths := config.NewPaths(dir, filepath.Join(dir, "config.yaml"))
+
+	database, err := db.Open(paths.DBPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	var entries []model.Entry
+	for _, name := range []string{"every", "filter", "find", "map", "reduce", "some"} {
+		entries = append(entries, model.Entry{
+			Docset:  "js",
+			Symbol:  "Array.prototype." + name,
+			Title:   name,
+			Path:    "array/" + name,
+			Content: "# " + name + "\n\nCalls a function for each element of the array.",
+		})
+	}
+	docset := model.Docset{Slug: "js", Name: "js", DisplayName: "JavaScript"}
+	if err := db.NewIndexer(database).IndexDocset(docset, entries); err != nil {
+		t.Fatalf("IndexDocset: %v", err)
+	}
+	database.Close()
+
+	application, err := app.NewWithPaths(paths)
+	if err != nil {
+		t.Fatalf("NewWithPaths: %v", err)
+	}
+	t.Cleanup(func() { application.Close() })
+	return application
+}
+
+// get serves a GET for target, with a bearer token when token isn't empty
+func get(s *Server, target, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+// decode unmarshals a response body into v
+func decode(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body.String(), err)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	s := New(newTestApp(t), testToken)
+
+	tests := []struct {
+		name   string
+		target string
+		header map[string]string
+		cookie string
+		want   int
+	}{
+		{name: "no token", target: "/api/docsets", want: http.StatusUnauthorized},
+		{name: "bearer", target: "/api/docsets", header: map[string]string{"Authorization": "Bearer " + testToken}, want: http.StatusOK},
+		{name: "wrong bearer", target: "/api/docsets", header: map[string]string{"Authorization": "Bearer nope"}, want: http.StatusUnauthorized},
+		{name: "basic auth", target: "/api/docsets", header: map[string]string{"Authorization": "Basic " + testToken}, want: http.StatusUnauthorized},
+		{name: "cookie", target: "/api/docsets", cookie: testToken, want: http.StatusOK},
+		{name: "wrong cookie", target: "/api/docsets", cookie: "nope", want: http.StatusUnauthorized},
+		{name: "query token", target: "/api/docsets?token=" + testToken, want: http.StatusOK},
+		{name: "wrong query token", target: "/api/docsets?token=nope", want: http.StatusUnauthorized},
+		{
+			name:   "wrong bearer beats a right query token",
+			target: "/api/docsets?token=" + testToken,
+			header: map[string]string{"Authorization": "Bearer nope"},
+			want:   http.StatusUnauthorized,
+		},
+		{name: "page without token", target: "/", want: http.StatusUnauthorized},
+		{name: "page with query token", target: "/?token=" + testToken, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		for k, v := range tt.header {
+			r.Header.Set(k, v)
+		}
+		if tt.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: tokenCookie, Value: tt.cookie})
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d; want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestAuthQueryTokenSetsCookie(t *testing.T) {
+	s := New(newTestApp(t), testToken)
+
+	w := get(s, "/?token="+testToken, "")
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != tokenCookie || cookies[0].Value != testToken || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %+v; want an HttpOnly %s cookie", cookies, tokenCookie)
+	}
+
+	// The cookie alone signs in later requests
+	r := httptest.NewRequest(http.MethodGet, "/api/docsets", nil)
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("status with cookie %d; want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestAuthErrorBody(t *testing.T) {
+	s := New(newTestApp(t), testToken)
+
+	w := get(s, "/api/search?q=map", "")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d; want %d", w.Code, http.StatusUnauthorized)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type %q; want JSON", ct)
+	}
+	if got := w.Header().Get("WWW-Authenticate"); !strings.HasPrefix(got, "Bearer") {
+		t.Errorf("WWW-Authenticate %q; want a Bearer challenge", got)
+	}
+	var body map[string]string
+	decode(t, w, &body)
+	if body["error"] == "" {
+		t.Errorf("body %q; want an error message", w.Body.String())
+	}
+}
+
+func TestNoTokenNeeded(t *testing.T) {
+	s := New(newTestApp(t), "")
+	if w := get(s, "/api/docsets", ""); w.Code != http.StatusOK {
+		t.Errorf("status %d; want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestPageParams(t *testing.T) {
+	tests := []struct {
+		limit, offset string
+		wantLimit     int
+		wantOffset    int
+		wantErr       bool
+	}{
+		{"", "", defaultLimit, 0, false},
+		{"1", "0", 1, 0, false},
+		{fmt.Sprint(app.MaxLimit), "20", app.MaxLimit, 20, false},
+		{"0", "", 0, 0, true},
+		{"-1", "", 0, 0, true},
+		{fmt.Sprint(app.MaxLimit + 1), "", 0, 0, true},
+		{"ten", "", 0, 0, true},
+		{"", "-1", 0, 0, true},
+		{"", "x", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		limit, offset, err := pageParams(tt.limit, tt.offset)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("pageParams(%q, %q) error = %v; want error %v", tt.limit, tt.offset, err, tt.wantErr)
+			continue
+		}
+		if limit != tt.wantLimit || offset != tt.wantOffset {
+			t.Errorf("pageParams(%q, %q) = %d, %d; want %d, %d", tt.limit, tt.offset, limit, offset, tt.wantLimit, tt.wantOffset)
+		}
+	}
+}
+
+func TestSearchBounds(t *testing.T) {
+	s := New(newTestApp(t), "")
+
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/api/search?q=map", http.StatusOK},
+		{"/api/search", http.StatusBadRequest},
+		{"/api/search?q=map&limit=0", http.StatusBadRequest},
+		{fmt.Sprintf("/api/search?q=map&limit=%d", app.MaxLimit+1), http.StatusBadRequest},
+		{"/api/search?q=map&offset=-5", http.StatusBadRequest},
+		{"/api/docsets/js/entries?limit=1000", http.StatusBadRequest},
+		{"/api/docsets/js/entries?after=%21%21", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		if w := get(s, tt.target, ""); w.Code != tt.want {
+			t.Errorf("GET %s: status %d; want %d (%s)", tt.target, w.Code, tt.want, w.Body.String())
+		}
+	}
+}
+
+func TestEntriesCursor(t *testing.T) {
+	s := New(newTestApp(t), "")
+
+	var symbols []string
+	target := "/api/docsets/js/entries?limit=4"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("cursor never ran out")
+		}
+		w := get(s, target, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d (%s)", target, w.Code, w.Body.String())
+		}
+		var resp entriesResponse
+		decode(t, w, &resp)
+		if resp.Total != 6 {
+			t.Errorf("total %d; want 6", resp.Total)
+		}
+		for _, e := range resp.Entries {
+			symbols = append(symbols, e.Symbol)
+		}
+		if resp.Next == "" {
+			break
+		}
+		target = "/api/docsets/js/entries?limit=4&after=" + resp.Next
+	}
+
+	want := "Array.prototype.every Array.prototype.filter Array.prototype.find Array.prototype.map Array.prototype.reduce Array.prototype.some"
+	if got := strings.Join(symbols, " "); got != want {
+		t.Errorf("paged symbols %q; want %q", got, want)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	s := New(newTestApp(t), "")
+
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/api/docsets/js/entries/array/map", http.StatusOK},
+		{"/api/docsets/js/entries/array/flat", http.StatusNotFound},
+		{"/api/docsets/ruby/entries/array/map", http.StatusNotFound},
+		{"/api/docsets/ruby/entries", http.StatusNotFound},
+		{"/api/resolve?docset=js&ref=Array.prototype.map", http.StatusOK},
+		{"/api/resolve?docset=js&ref=Array.prototype.flat", http.StatusNotFound},
+		{"/api/resolve?docset=ruby&ref=map", http.StatusNotFound},
+		{"/api/related?docset=js&path=array/flat", http.StatusNotFound},
+		{"/api/nope", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		w := get(s, tt.target, "")
+		if w.Code != tt.want {
+			t.Errorf("GET %s: status %d; want %d (%s)", tt.target, w.Code, tt.want, w.Body.String())
+			continue
+		}
+		if w.Code == http.StatusNotFound {
+			var body map[string]string
+			decode(t, w, &body)
+			if body["error"] == "" {
+				t.Errorf("GET %s: body %q; want an error message", tt.target, w.Body.String())
+			}
+		}
+	}
+}
+
+func TestResolveDatabaseFailure(t *testing.T) {
+	application := newTestApp(t)
+	s := New(application, "")
+	application.Close()
+
+	if w := get(s, "/api/resolve?docset=js&ref=map", ""); w.Code != http.StatusInternalServerError {
+		t.Errorf("status %d; want %d (%s)", w.Code, http.StatusInternalServerError, w.Body.String())
+	}
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+	"github.com/lazydocs/lazydocs/internal/render"
+)
+
+// webLimit is how many results or entries a page lists
+const webLimit = 200
+
+var templateFuncs = template.FuncMap{
+	"snippet":   snippetHTML,
+	"entryURL":  entryURL,
+	"docsetURL": func(slug string) string { return "/docs/" + url.PathEscape(slug) + "/" },
+	"slug": func(docset, version string) string {
+		return model.Docset{Name: docset, Version: version}.FullSlug()
+	},
+}
+
+// page is the data every template gets
+type page struct {
+	Title   string
+	Query   string
+	Docset  string // Slug searches are limited to, if any
+	Error   string
+	Docsets []model.Docset
+	Results []model.SearchResult
+	Total   int
+	Entries []model.Entry
+	Next    string // URL of the next page of entries
+	Entry   *model.Entry
+	Content template.HTML
+}
+
+func (s *Server) pageIndex(w http.ResponseWriter, r *http.Request) {
+	docsets, err := s.app.ListInstalledDocsets()
+	if err != nil {
+		s.renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.render(w, "index.html", page{Title: "lazydocs", Docsets: docsets})
+}
+
+func (s *Server) pageSearch(w http.ResponseWriter, r *http.Request) {
+	p := page{Query: r.URL.Query().Get("q"), Docset: r.URL.Query().Get("docset")}
+	p.Title = p.Query + " - lazydocs"
+	if p.Query == "" {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	name, version := model.ParseSlug(p.Docset)
+	results, err := s.app.Search(p.Query, name, version, webLimit, 0)
+	if err == nil {
+		p.Results = results
+		p.Total, err = s.app.CountResults(p.Query, name, version)
+	}
+	if err != nil {
+		// Mostly malformed queries, which the page explains
+		p.Error = err.Error()
+	}
+	s.render(w, "search.html", p)
+}
+
+func (s *Server) pageDocset(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	name, version := model.ParseSlug(slug)
+	after, err := model.ParseCursor(r.URL.Query().Get("after"))
+	if err != nil {
+		s.renderError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	total, err := s.app.CountEntries(name, version)
+	if err != nil {
+		s.renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if total == 0 {
+		s.renderError(w, http.StatusNotFound, errors.New("docset "+slug+" is not installed"))
+		return
+	}
+	entries, err := s.app.ListEntries(name, version, after, webLimit+1)
+	if err != nil {
+		s.renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	p := page{Title: slug + " - lazydocs", Docset: slug, Entries: entries, Total: total}
+	if len(entries) > webLimit {
+		p.Entries = entries[:webLimit]
+		p.Next = "?after=" + entries[webLimit-1].Cursor()
+	}
+	s.render(w, "docset.html", p)
+}
+
+func (s *Server) pageEntry(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.entry(r)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.renderError(w, http.StatusNotFound, errors.New("no such entry"))
+		return
+	}
+	if err != nil {
+		s.renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	content, err := render.HTML(entry.Content)
+	if err != nil {
+		s.renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.render(w, "entry.html", page{
+		Title:   entry.Symbol + " - " + r.PathValue("slug"),
+		Docset:  r.PathValue("slug"),
+		Entry:   entry,
+		Content: template.HTML(content),
+	})
+}
+
+func (s *Server) renderError(w http.ResponseWriter, status int, err error) {
+	// Headers set after WriteHeader are dropped
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	s.render(w, "error.html", page{Title: http.StatusText(status), Error: err.Error()})
+}
+
+func (s *Server) render(w http.ResponseWriter, name string, p page) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.pages.ExecuteTemplate(w, name, p); err != nil {
+		log.Printf("failed to render %s: %v", name, err)
+	}
+}
+
+// entryURL returns the web UI page of an entry
+func entryURL(e model.Entry) string {
+	slug := model.Docset{Name: e.Docset, Version: e.Version}.FullSlug()
+	return "/docs/" + url.PathEscape(slug) + "/" + (&url.URL{Path: e.Path}).EscapedPath()
+}
+
+// snippetHTML escapes an FTS5 snippet, keeping its <mark> highlighting
+func snippetHTML(snippet string) template.HTML {
+	escaped := template.HTMLEscapeString(snippet)
+	escaped = strings.NewReplacer("&lt;mark&gt;", "<mark>", "&lt;/mark&gt;", "</mark>").Replace(escaped)
+	return template.HTML(escaped)
+}
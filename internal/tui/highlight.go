@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lazydocs/lazydocs/internal/model"
+)
+
+// Reverse video keeps whatever colors glamour chose for the text underneath
+const (
+	highlightOn  = "\x1b[7m"
+	highlightOff = "\x1b[27m"
+)
+
+// ansiSeq matches the SGR escape sequences glamour emits
+var ansiSeq = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// renderSnippet turns an FTS5 snippet with <mark> tags into a single
+// styled line
+func renderSnippet(snippet string) string {
+	snippet = strings.Join(strings.Fields(snippet), " ")
+
+	var b strings.Builder
+	for {
+		start := strings.Index(snippet, "<mark>")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(snippet[start:], "</mark>")
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(helpStyle.Render(snippet[:start]))
+		b.WriteString(matchStyle.Render(snippet[start+len("<mark>") : end]))
+		snippet = snippet[end+len("</mark>"):]
+	}
+	b.WriteString(helpStyle.Render(snippet))
+	return b.String()
+}
+
+// exampleSnippet is the one-line summary of a code example in the results
+// pane: its language and first non-blank line
+func exampleSnippet(x model.Example) string {
+	first := ""
+	for _, line := range strings.Split(x.Code, "\n") {
+		if strings.TrimSpace(line) != "" {
+			first = strings.TrimSpace(line)
+			break
+		}
+	}
+	if x.Language != "" {
+		return matchStyle.Render(x.Language) + " " + helpStyle.Render(first)
+	}
+	return helpStyle.Render(first)
+}
+
+// highlightTerms highlights every occurrence of the terms at the start of a
+// word in rendered (ANSI-styled) content. It returns the highlighted
+// content and the first line with a match, or -1 if there is none.
+func highlightTerms(content string, terms []string) (string, int) {
+	if len(terms) == 0 {
+		return content, -1
+	}
+
+	folded := make([][]rune, len(terms))
+	for i, t := range terms {
+		folded[i] = lowerRunes(t)
+	}
+
+	first := -1
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if highlighted, ok := highlightLine(line, folded); ok {
+			lines[i] = highlighted
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	return strings.Join(lines, "\n"), first
+}
+
+// highlightLine highlights the lowercased terms in one line, skipping over
+// escape sequences so styles and matches can interleave
+func highlightLine(line string, terms [][]rune) (string, bool) {
+	// The visible runes, lowercased, and their byte offsets in line
+	var plain []rune
+	var offsets []int
+	seqs := ansiSeq.FindAllStringIndex(line, -1)
+	for i := 0; i < len(line); {
+		if len(seqs) > 0 && seqs[0][0] == i {
+			i = seqs[0][1]
+			seqs = seqs[1:]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		plain = append(plain, unicode.ToLower(r))
+		offsets = append(offsets, i)
+		i += size
+	}
+
+	marked := make([]bool, len(plain))
+	found := false
+	for _, t := range terms {
+		for j := 0; j+len(t) <= len(plain); j++ {
+			if j > 0 && isWordChar(plain[j-1]) {
+				continue
+			}
+			if string(plain[j:j+len(t)]) == string(t) {
+				for k := j; k < j+len(t); k++ {
+					marked[k] = true
+				}
+				found = true
+			}
+		}
+	}
+	if !found {
+		return line, false
+	}
+
+	var b strings.Builder
+	on := false
+	prevEnd := 0
+	for k, offset := range offsets {
+		between := line[prevEnd:offset]
+		b.WriteString(between)
+
+		// A reset inside a match would switch the highlight off, so
+		// restore it after any escape sequence
+		if marked[k] && (!on || between != "") {
+			b.WriteString(highlightOn)
+			on = true
+		} else if !marked[k] && on {
+			b.WriteString(highlightOff)
+			on = false
+		}
+
+		_, size := utf8.DecodeRuneInString(line[offset:])
+		b.WriteString(line[offset : offset+size])
+		prevEnd = offset + size
+	}
+	if on {
+		b.WriteString(highlightOff)
+	}
+	b.WriteString(line[prevEnd:])
+	return b.String(), true
+}
+
+// lowerRunes lowercases s rune by rune, so offsets line up with the input
+func lowerRunes(s string) []rune {
+	rs := []rune(s)
+	for i, r := range rs {
+		rs[i] = unicode.ToLower(r)
+	}
+	return rs
+}
+
+// isWordChar reports whether r is part of a word
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// locateHeadings finds the line of rendered content where each heading
+// starts, searching in order. Headings that can't be found get -1.
+func locateHeadings(content string, headings []model.Heading) []int {
+	if len(headings) == 0 {
+		return nil
+	}
+
+	lines := strings.Split(content, "\n")
+	located := make([]int, len(headings))
+	next := 0
+	for i, h := range headings {
+		located[i] = -1
+		want := strings.Join(strings.Fields(h.Text), " ")
+		for j := next; j < len(lines); j++ {
+			text := strings.Join(strings.Fields(ansiSeq.ReplaceAllString(lines[j], "")), " ")
+			text = strings.TrimSpace(strings.TrimLeft(text, "#"))
+			if text == "" {
+				continue
+			}
+			// Long headings wrap, so the first line holds only a prefix
+			if text == want || (len(text) >= min(len(want), 10) && strings.HasPrefix(want, text)) {
+				located[i] = j
+				next = j + 1
+				break
+			}
+		}
+	}
+	return located
+}
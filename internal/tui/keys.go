@@ -4,28 +4,36 @@ import "github.com/charmbracelet/bubbles/key"
 
 type keyMap struct {
 	// Navigation
-	Up        key.Binding
-	Down      key.Binding
-	Left      key.Binding
-	Right     key.Binding
-	Tab       key.Binding
-	ShiftTab  key.Binding
-	Enter     key.Binding
-	Back      key.Binding
-	Top       key.Binding
-	Bottom    key.Binding
-	HalfDown  key.Binding
-	HalfUp    key.Binding
+	Up       key.Binding
+	Down     key.Binding
+	Left     key.Binding
+	Right    key.Binding
+	Tab      key.Binding
+	ShiftTab key.Binding
+	Enter    key.Binding
+	Back     key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	HalfDown key.Binding
+	HalfUp   key.Binding
 
 	// Actions
 	Search      key.Binding
 	LocalSearch key.Binding
+	FuzzyToggle key.Binding
+	CodeToggle  key.Binding
+	Copy        key.Binding
+	Outline     key.Binding
+	Diff        key.Binding
+	DiffLayout  key.Binding
+	Related     key.Binding
+	OpenRelated key.Binding
 	Add         key.Binding
-	Delete    key.Binding
-	Update    key.Binding
-	Help      key.Binding
-	Quit      key.Binding
-	Escape    key.Binding
+	Delete      key.Binding
+	Update      key.Binding
+	Help        key.Binding
+	Quit        key.Binding
+	Escape      key.Binding
 }
 
 var keys = keyMap{
@@ -85,6 +93,40 @@ var keys = keyMap{
 		key.WithKeys("s"),
 		key.WithHelp("s", "search docset"),
 	),
+	// Search toggles work while typing a query, so they must not be one of
+	// the text input's editing keys (ctrl+a, b, d, e, f, h, k, n, p, u, v, w)
+	FuzzyToggle: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "toggle fuzzy search"),
+	),
+	CodeToggle: key.NewBinding(
+		key.WithKeys("ctrl+x"),
+		key.WithHelp("ctrl+x", "toggle code example search"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy code example"),
+	),
+	Outline: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "outline"),
+	),
+	Diff: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "diff with other versions"),
+	),
+	DiffLayout: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "toggle side-by-side diff"),
+	),
+	Related: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "toggle related entries"),
+	),
+	OpenRelated: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5"),
+		key.WithHelp("1-5", "open related entry"),
+	),
 	Add: key.NewBinding(
 		key.WithKeys("a"),
 		key.WithHelp("a", "add docset"),
@@ -1,10 +1,14 @@
 package tui
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/lazydocs/lazydocs/internal/app"
 	"github.com/lazydocs/lazydocs/internal/model"
+	"github.com/lazydocs/lazydocs/internal/project"
 )
 
 // Mode represents the current UI mode
@@ -16,6 +20,8 @@ const (
 	ModeHelp
 	ModeDocsetPicker
 	ModeDeleteConfirm
+	ModeOutline
+	ModeInstallPrompt
 )
 
 // Pane represents which pane is focused
@@ -26,19 +32,42 @@ const (
 	PanePreview
 )
 
+const (
+	// pageSize is how many results are fetched at a time
+	pageSize = 100
+
+	// loadMoreThreshold is how close to the end of the loaded results the
+	// selection gets before the next page is fetched
+	loadMoreThreshold = 20
+
+	// relatedLimit is how many related entries are listed, and
+	// relatedHeight the lines their section takes below the preview
+	relatedLimit  = 5
+	relatedHeight = relatedLimit + 2
+)
+
+// resultSource identifies what filled the results pane, so further pages
+// can be fetched. An empty query means a docset listing.
+type resultSource struct {
+	query   string
+	docset  string
+	version string
+	code    bool // Searching code examples only
+}
+
 // Model is the main Bubbletea model
 type Model struct {
-	// App reference
-	app *app.App
+	// Index: the local app or a lazydocs server
+	app app.Backend
 
 	// Dimensions
 	width  int
 	height int
 
 	// State
-	mode        Mode
-	activePane  Pane
-	activeTab   int
+	mode       Mode
+	activePane Pane
+	activeTab  int
 
 	// Data
 	docsets     []model.Docset
@@ -49,17 +78,46 @@ type Model struct {
 	searchInput textinput.Model
 	preview     viewport.Model
 
-	// Navigation history
-	history []string
+	// Navigation history of related entries opened in the preview
+	history []model.Entry
+	viewing *model.Entry // Related entry shown instead of the selection
+
+	// Outline of the previewed entry, with the preview line of each
+	// heading (-1 where it couldn't be found)
+	headings     []model.Heading
+	headingLines []int
+	outlineIdx   int
+
+	// Other version of the docset the preview is diffed against, if any,
+	// and the comparison on screen once it has loaded
+	diffSlug       string
+	diff           *diffLoadedMsg
+	diffSideBySide bool
+
+	// First key of a two-key sequence such as "]]"
+	pendingKey string
+
+	// Related entries section below the preview
+	showRelated    bool
+	related        []model.SearchResult
+	relatedLoading bool
+
+	// The preview and its related entries load in the background:
+	// previewWant and relatedWant are the entries to load once Update
+	// returns, and previewSeq tells a load for an earlier preview apart
+	previewWant *model.Entry
+	relatedWant *model.Entry
+	previewSeq  int
 
 	// Status
-	statusMsg   string
+	statusMsg    string
 	initialQuery string
 
 	// Available docsets (for picker)
 	availableDocsets model.Manifest
 	pickerIdx        int
 	pickerSearch     textinput.Model
+	pickerErr        error // Why the list couldn't be loaded, e.g. a remote backend
 
 	// Download progress
 	downloading    bool
@@ -67,6 +125,18 @@ type Model struct {
 	downloadPct    float64
 	downloadStatus string
 
+	// Docsets waiting to be installed one after another, offered in
+	// ModeInstallPrompt before they start, and the hint shown when the
+	// offer is declined
+	installQueue []string
+	installHint  string
+
+	// Offer the docsets detected from the working directory's dependency
+	// files once the UI is up, and the directory to record as offered once
+	// the prompt is answered
+	detectDocsets bool
+	offerDir      string
+
 	// Config
 	theme     string // "dark", "light", "dracula", "notty"
 	showDebug bool
@@ -74,11 +144,39 @@ type Model struct {
 	// Debug
 	lastKey string
 
-
 	// Track if search filter is active (for Escape to clear)
 	searchActive    bool
 	globalSearch    bool // true = search all docsets, false = current docset only
+	fuzzySearch     bool // true = fzf-style symbol matching instead of full-text
+	codeSearch      bool // true = search code examples instead of full-text
 	lastRenderedIdx int  // Track which entry was last rendered in preview
+
+	// Matched rune offsets in each entry's symbol, from fuzzy search
+	matches [][]int
+
+	// Highlighted content excerpt for each entry, from full-text search
+	snippets []string
+
+	// Code block behind each entry, from code example search
+	examples []model.Example
+
+	// Query terms to highlight in the preview
+	terms []string
+
+	// Error from the last search, such as a malformed query
+	searchErr string
+
+	// Respelled query offered when a search finds little
+	suggestion string
+
+	// Latest first page asked of the results pane; replies to earlier ones,
+	// such as a slow search for a shorter query, are dropped
+	searchSeq int
+
+	// Pagination of the results pane
+	source      resultSource
+	total       int  // Total matches, loaded or not
+	loadingMore bool // A further page is being fetched
 }
 
 // New creates a new Model with demo data (for testing without app)
@@ -124,8 +222,8 @@ func New() Model {
 	}
 }
 
-// NewWithApp creates a new Model connected to the app
-func NewWithApp(application *app.App, lookup string) Model {
+// NewWithApp creates a new Model connected to a backend
+func NewWithApp(application app.Backend, lookup string) Model {
 	m := New()
 	m.app = application
 	m.initialQuery = lookup
@@ -138,19 +236,23 @@ func NewWithApp(application *app.App, lookup string) Model {
 	}
 	m.showDebug = cfg.UI.ShowDebug
 
-	// Load installed docsets
-	docsets, err := application.ListInstalledDocsets()
+	// Load installed docsets, only the project's inside a project
+	docsets, err := application.ScopedDocsets()
 	if err == nil && len(docsets) > 0 {
 		m.docsets = docsets
 
 		// Load entries for first docset
-		entries, err := application.ListEntries(docsets[0].Name, docsets[0].Version, 100)
+		ds := docsets[0]
+		entries, err := application.ListEntries(ds.Name, ds.Version, nil, pageSize)
 		if err == nil {
 			m.entries = entries
+			m.source = resultSource{docset: ds.Name, version: ds.Version}
+			m.total, _ = application.CountEntries(ds.Name, ds.Version)
 		}
 	} else {
 		m.docsets = nil
 		m.entries = nil
+		m.total = 0
 	}
 
 	// If lookup query provided, start in search mode
@@ -158,11 +260,33 @@ func NewWithApp(application *app.App, lookup string) Model {
 		m.searchInput.SetValue(lookup)
 		m.mode = ModeSearch
 		m.searchInput.Focus()
+	} else if missing := missingProjectDocsets(application); len(missing) > 0 {
+		// Offer the docsets the project file declares but that aren't installed
+		m.installQueue = missing
+		m.installHint = "Run 'lazydocs sync' to install them later"
+		m.mode = ModeInstallPrompt
+		m.statusMsg = fmt.Sprintf("Install %s from %s? (y/n)", strings.Join(missing, ", "), project.FileName)
+	} else if application.Project() == nil {
+		m.detectDocsets = true
 	}
 
 	return m
 }
 
+// missingProjectDocsets returns the docsets the app's project declares
+// that aren't installed
+func missingProjectDocsets(application app.Backend) []string {
+	p := application.Project()
+	if p == nil {
+		return nil
+	}
+	installed, err := application.ListInstalledDocsets()
+	if err != nil {
+		return nil
+	}
+	return p.Missing(installed)
+}
+
 // currentDocset returns the currently active docset
 func (m Model) currentDocset() *model.Docset {
 	if m.activeTab >= 0 && m.activeTab < len(m.docsets) {
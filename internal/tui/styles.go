@@ -46,6 +46,15 @@ var (
 	normalItemStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("252"))
 
+	// Search errors, such as malformed queries
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("203"))
+
+	// Characters matched by fuzzy search
+	matchStyle = lipgloss.NewStyle().
+			Foreground(colorPrimary).
+			Bold(true)
+
 	// Status bar styles
 	statusBarStyle = lipgloss.NewStyle().
 			Foreground(colorMuted).
@@ -1,13 +1,21 @@
 package tui
 
 import (
+	"fmt"
+	"os"
+	"slices"
 	"strings"
 
+	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/glamour"
+	"github.com/lazydocs/lazydocs/internal/app"
 	"github.com/lazydocs/lazydocs/internal/data"
+	"github.com/lazydocs/lazydocs/internal/db"
+	"github.com/lazydocs/lazydocs/internal/diff"
 	"github.com/lazydocs/lazydocs/internal/model"
+	"github.com/lazydocs/lazydocs/internal/project"
+	"github.com/lazydocs/lazydocs/internal/render"
 )
 
 // Messages for async operations
@@ -15,6 +23,33 @@ type startDownloadMsg struct {
 	slug string
 }
 
+type previewLoadedMsg struct {
+	seq      int
+	entry    model.Entry
+	headings []model.Heading
+}
+
+type relatedLoadedMsg struct {
+	seq     int
+	related []model.SearchResult
+}
+
+// diffLoadedMsg carries an entry in two versions of a docset, the older
+// as from and the newer as to, whichever of them is on screen
+type diffLoadedMsg struct {
+	seq              int
+	symbol           string
+	other            string // Version cycled to, e.g. "rails~8.0"
+	fromSlug, toSlug string
+	from, to         *model.Entry
+	err              error
+}
+
+type detectedDocsetsMsg struct {
+	dir         string
+	suggestions []project.Suggestion
+}
+
 type docsetInstalledMsg struct {
 	slug string
 	err  error
@@ -27,12 +62,37 @@ type downloadProgressMsg struct {
 }
 
 type searchResultsMsg struct {
+	seq     int
+	source  resultSource
 	results []model.SearchResult
+	total   int
+	offset  int // Non-zero when this is a further page of results
+	err     error
+
+	suggestion string // "Did you mean" respelling of the query
+}
+
+type exampleResultsMsg struct {
+	seq      int
+	source   resultSource
+	examples []model.Example
+	total    int
+	offset   int // Non-zero when this is a further page of results
+	err      error
+}
+
+type fuzzyResultsMsg struct {
+	seq     int
+	results []model.FuzzyResult
 	err     error
 }
 
 type entriesLoadedMsg struct {
+	seq     int
+	source  resultSource
 	entries []model.Entry
+	total   int
+	more    bool // True when this is a further page of entries
 	err     error
 }
 
@@ -50,11 +110,34 @@ func (m Model) Init() tea.Cmd {
 		cmds = append(cmds, m.doSearch(m.initialQuery))
 	}
 
+	if m.detectDocsets && m.app != nil {
+		cmds = append(cmds, m.offerDetectedDocsets())
+	}
+
 	return tea.Batch(cmds...)
 }
 
 // Update implements tea.Model
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+
+	// Start loading whatever the preview asked for while handling msg
+	m, ok := next.(Model)
+	if !ok {
+		return next, cmd
+	}
+	if m.previewWant != nil {
+		cmd = tea.Batch(cmd, m.loadPreview(*m.previewWant))
+		m.previewWant = nil
+	}
+	if m.relatedWant != nil {
+		cmd = tea.Batch(cmd, m.loadRelated(*m.relatedWant))
+		m.relatedWant = nil
+	}
+	return m, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -71,6 +154,41 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.downloadPct = float64(msg.downloaded) / float64(msg.total) * 100
 		m.downloadStatus = msg.status
 
+	case previewLoadedMsg:
+		// A newer selection may have been made while this one loaded
+		if msg.seq == m.previewSeq {
+			m = m.showPreview(msg.entry, msg.headings)
+		}
+
+	case relatedLoadedMsg:
+		if msg.seq == m.previewSeq && m.showRelated {
+			m.related = msg.related
+			m.relatedLoading = false
+		}
+
+	case diffLoadedMsg:
+		if msg.seq == m.previewSeq {
+			m = m.showDiff(msg)
+		}
+
+	case detectedDocsetsMsg:
+		// Only interrupt if nothing else has been started meanwhile
+		if len(msg.suggestions) > 0 && m.mode == ModeNormal && !m.downloading {
+			var sources []string
+			m.installQueue = nil
+			for _, s := range msg.suggestions {
+				m.installQueue = append(m.installQueue, s.Slug)
+				if !slices.Contains(sources, s.Source) {
+					sources = append(sources, s.Source)
+				}
+			}
+			m.installHint = "Run 'lazydocs detect' to see them again"
+			m.offerDir = msg.dir
+			m.mode = ModeInstallPrompt
+			m.statusMsg = fmt.Sprintf("Install %s, detected from %s? (y/n)",
+				strings.Join(m.installQueue, ", "), strings.Join(sources, ", "))
+		}
+
 	case docsetInstalledMsg:
 		m.downloading = false
 		m.downloadSlug = ""
@@ -80,7 +198,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.statusMsg = "Installed successfully"
 			// Reload docsets
 			if m.app != nil {
-				docsets, err := m.app.ListInstalledDocsets()
+				docsets, err := m.app.ScopedDocsets()
 				if err == nil {
 					m.docsets = docsets
 					// Switch to the new docset
@@ -88,35 +206,174 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						if ds.Slug == msg.slug {
 							m.activeTab = i
 							// Load entries
-							cmds = append(cmds, m.loadEntries(ds.Name, ds.Version))
+							var cmd tea.Cmd
+							m, cmd = m.loadEntries(ds.Name, ds.Version)
+							cmds = append(cmds, cmd)
 							break
 						}
 					}
 				}
+				if p := m.app.Project(); p != nil && !p.Declares(msg.slug) {
+					m.statusMsg = "Installed " + msg.slug + "; add it to " + project.FileName + " to see it here"
+				}
 			}
 		}
 		m.mode = ModeNormal
 
+		// Go on with the next queued docset
+		if len(m.installQueue) > 0 {
+			var cmd tea.Cmd
+			m, cmd = m.startQueuedInstall()
+			cmds = append(cmds, cmd)
+		}
+
 	case searchResultsMsg:
-		if msg.err == nil {
+		if msg.offset > 0 {
+			// Drop pages for results that have since been replaced
+			m.loadingMore = false
+			if msg.err == nil && msg.source == m.source && msg.offset == len(m.entries) {
+				for _, r := range msg.results {
+					m.entries = append(m.entries, r.Entry)
+					m.snippets = append(m.snippets, renderSnippet(r.Snippet))
+				}
+			}
+			break
+		}
+		if msg.seq != m.searchSeq {
+			break // A later search has been started
+		}
+
+		m.searchErr = ""
+		if msg.err != nil {
+			m.searchErr = msg.err.Error()
+		} else {
+			m.entries = make([]model.Entry, len(msg.results))
+			m.snippets = make([]string, len(msg.results))
+			for i, r := range msg.results {
+				m.entries[i] = r.Entry
+				m.snippets[i] = renderSnippet(r.Snippet)
+			}
+			m.matches = nil
+			m.examples = nil
+			m.suggestion = msg.suggestion
+			m.terms = nil
+			if q, err := db.ParseQuery(msg.source.query); err == nil {
+				m.terms = q.Terms()
+			}
+			m.source = msg.source
+			m.total = msg.total
+			m.loadingMore = false
+			m.selectedIdx = 0
+			m.lastRenderedIdx = -1 // Force preview update
+			m = m.updatePreviewContent()
+		}
+
+	case exampleResultsMsg:
+		if msg.offset > 0 {
+			// Drop pages for results that have since been replaced
+			m.loadingMore = false
+			if msg.err == nil && msg.source == m.source && msg.offset == len(m.entries) {
+				for _, x := range msg.examples {
+					m.entries = append(m.entries, x.Entry)
+					m.examples = append(m.examples, x)
+					m.snippets = append(m.snippets, exampleSnippet(x))
+				}
+			}
+			break
+		}
+		if msg.seq != m.searchSeq {
+			break // A later search has been started
+		}
+
+		m.searchErr = ""
+		if msg.err != nil {
+			m.searchErr = msg.err.Error()
+		} else {
+			m.entries = make([]model.Entry, len(msg.examples))
+			m.snippets = make([]string, len(msg.examples))
+			m.examples = msg.examples
+			if m.examples == nil {
+				m.examples = []model.Example{}
+			}
+			for i, x := range msg.examples {
+				m.entries[i] = x.Entry
+				m.snippets[i] = exampleSnippet(x)
+			}
+			m.matches = nil
+			m.suggestion = ""
+			m.terms = nil
+			if q, err := db.ParseQuery(msg.source.query); err == nil {
+				m.terms = q.Terms()
+			}
+			m.source = msg.source
+			m.total = msg.total
+			m.loadingMore = false
+			m.selectedIdx = 0
+			m.lastRenderedIdx = -1 // Force preview update
+			m = m.updatePreviewContent()
+		}
+
+	case fuzzyResultsMsg:
+		if msg.seq != m.searchSeq {
+			break // A later search has been started
+		}
+
+		m.searchErr = ""
+		if msg.err != nil {
+			m.searchErr = msg.err.Error()
+		} else {
 			m.entries = make([]model.Entry, len(msg.results))
+			m.matches = make([][]int, len(msg.results))
 			for i, r := range msg.results {
 				m.entries[i] = r.Entry
+				m.matches[i] = r.Positions
 			}
+			m.snippets = nil
+			m.examples = nil
+			m.suggestion = ""
+			m.terms = nil
+			// Fuzzy matches arrive all at once
+			m.source = resultSource{}
+			m.total = len(msg.results)
+			m.loadingMore = false
 			m.selectedIdx = 0
 			m.lastRenderedIdx = -1 // Force preview update
 			m = m.updatePreviewContent()
 		}
 
 	case entriesLoadedMsg:
-		if msg.err == nil {
+		if msg.more {
+			// Drop pages for a listing that has since been replaced
+			m.loadingMore = false
+			if msg.err == nil && msg.source == m.source {
+				m.entries = append(m.entries, msg.entries...)
+			}
+			break
+		}
+		if msg.seq != m.searchSeq {
+			break // A later search or listing has been started
+		}
+
+		m.searchErr = ""
+		if msg.err != nil {
+			m.searchErr = msg.err.Error()
+		} else {
 			m.entries = msg.entries
+			m.matches = nil
+			m.snippets = nil
+			m.examples = nil
+			m.suggestion = ""
+			m.terms = nil
+			m.source = msg.source
+			m.total = msg.total
+			m.loadingMore = false
 			m.selectedIdx = 0
 			m.lastRenderedIdx = -1 // Force preview update
 			m = m.updatePreviewContent()
 		}
 
 	case manifestLoadedMsg:
+		m.pickerErr = msg.err
 		if msg.err == nil {
 			m.availableDocsets = msg.manifest
 		}
@@ -147,6 +404,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateDocsetPicker(msg)
 		case ModeDeleteConfirm:
 			return m.updateDeleteConfirm(msg)
+		case ModeOutline:
+			return m.updateOutline(msg)
+		case ModeInstallPrompt:
+			return m.updateInstallPrompt(msg)
 		default:
 			return m.updateNormal(msg)
 		}
@@ -159,14 +420,20 @@ func (m Model) updateDimensions() Model {
 	// Calculate preview pane dimensions
 	previewWidth := (m.width * 2 / 3) - 4
 	previewHeight := m.height - 6
+	if m.showRelated {
+		previewHeight -= relatedHeight
+	}
 
 	if previewWidth > 0 && previewHeight > 0 {
 		m.preview.Width = previewWidth
 		m.preview.Height = previewHeight
 	}
 
-	// Update preview content
+	// Update preview content; a side-by-side diff is redrawn to the new width
 	m = m.updatePreviewContent()
+	if m.diff != nil && m.diffSideBySide {
+		m = m.renderDiff()
+	}
 
 	return m
 }
@@ -183,41 +450,294 @@ func (m Model) updatePreviewContent() Model {
 		return m
 	}
 
-	entry := m.entries[m.selectedIdx]
+	// Moving the selection leaves any related entry that was opened
+	m.viewing = nil
+	m.history = nil
 
-	// Render markdown content with configured theme
-	theme := m.theme
-	if theme == "" {
-		theme = "dark"
+	if m.examples != nil && m.selectedIdx < len(m.examples) {
+		m = m.renderExample(m.examples[m.selectedIdx])
+	} else {
+		m = m.renderPreview(m.entries[m.selectedIdx])
 	}
+	m.lastRenderedIdx = m.selectedIdx
+
+	return m
+}
 
-	renderer, err := glamour.NewTermRenderer(
-		glamour.WithStylePath(theme),
-		glamour.WithWordWrap(m.preview.Width),
-	)
+// renderExample shows a code example in the preview pane, under the name of
+// the entry it comes from
+func (m Model) renderExample(x model.Example) Model {
+	fence := "```"
+	for strings.Contains(x.Code, fence) {
+		fence += "`"
+	}
+	md := fmt.Sprintf("**%s** (%s)\n\n%s%s\n%s\n%s\n", x.Entry.Symbol, x.Entry.Docset, fence, x.Language, x.Code, fence)
 
-	var content string
-	if err != nil {
-		content = entry.Content
-	} else {
-		rendered, err := renderer.Render(entry.Content)
-		if err != nil {
-			content = entry.Content
-		} else {
-			content = rendered
+	content := md
+	if m.theme != "notty" {
+		if rendered, err := render.Markdown(md, m.theme, m.preview.Width); err == nil {
+			content, _ = highlightTerms(rendered, m.terms)
 		}
 	}
 
 	m.preview.SetContent(content)
 	m.preview.GotoTop()
-	m.lastRenderedIdx = m.selectedIdx
+	m.headings = nil
+	m.headingLines = nil
+	m.related = nil
+	m.relatedLoading = false
+
+	// Whatever was loading for the preview is no longer wanted
+	m.previewSeq++
+	m.previewWant = nil
+	m.relatedWant = nil
+	return m
+}
+
+// renderPreview shows an entry in the preview pane. With a backend, its
+// content and outline load in the background first.
+func (m Model) renderPreview(entry model.Entry) Model {
+	m.previewSeq++
+	m.diffSlug = ""
+	m.diff = nil
+	m.headings = nil
+	m.headingLines = nil
+	m.related = nil
+	m.relatedLoading = m.showRelated && m.app != nil
+	m.relatedWant = nil
+
+	if m.app == nil {
+		return m.showPreview(entry, nil)
+	}
+	m.previewWant = &entry
+	return m
+}
+
+// loadPreview loads an entry's content and outline in the background
+func (m Model) loadPreview(entry model.Entry) tea.Cmd {
+	application, seq := m.app, m.previewSeq
+	return func() tea.Msg {
+		// Lists and search results don't carry content, so load it on demand
+		if entry.Content == "" {
+			if full, err := application.GetEntry(entry.Docset, entry.Version, entry.Path); err == nil {
+				entry = *full
+			}
+		}
+
+		var headings []model.Heading
+		if entry.ID != 0 {
+			headings, _ = application.Headings(entry.ID)
+		}
+		return previewLoadedMsg{seq: seq, entry: entry, headings: headings}
+	}
+}
+
+// showPreview renders a loaded entry into the preview pane, along with its
+// related entries when that section is open
+func (m Model) showPreview(entry model.Entry, headings []model.Heading) Model {
+	// Render markdown content with configured theme, falling back to the
+	// raw Markdown
+	content, _ := render.Markdown(entry.Content, m.theme, m.preview.Width)
+
+	// Highlight the search terms and jump to the first one
+	first := -1
+	if m.theme != "notty" {
+		content, first = highlightTerms(content, m.terms)
+	}
+
+	m.preview.SetContent(content)
+	m.preview.GotoTop()
+	if first > 0 {
+		m.preview.SetYOffset(max(first-2, 0))
+	}
+
+	m.headings = headings
+	m.headingLines = locateHeadings(content, m.headings)
+
+	return m.requestRelated(entry)
+}
+
+// requestRelated clears the related entries and, when that section is
+// open, has Update load them for entry
+func (m Model) requestRelated(entry model.Entry) Model {
+	m.related = nil
+	m.relatedLoading = false
+	if m.showRelated && m.app != nil {
+		m.relatedWant = &entry
+		m.relatedLoading = true
+	}
+	return m
+}
+
+// loadRelated finds the entries related to entry in the background
+func (m Model) loadRelated(entry model.Entry) tea.Cmd {
+	application, seq := m.app, m.previewSeq
+	return func() tea.Msg {
+		related, _ := application.Related(entry, false, relatedLimit)
+		return relatedLoadedMsg{seq: seq, related: related}
+	}
+}
+
+// previewEntry returns the entry shown in the preview: an opened related
+// entry, or else the selected one
+func (m Model) previewEntry() *model.Entry {
+	if m.viewing != nil {
+		return m.viewing
+	}
+	if m.selectedIdx < len(m.entries) {
+		return &m.entries[m.selectedIdx]
+	}
+	return nil
+}
+
+// openRelated shows the nth related entry in the preview, remembering the
+// current one for Back
+func (m Model) openRelated(n int) Model {
+	if n >= len(m.related) {
+		return m
+	}
+	if current := m.previewEntry(); current != nil {
+		m.history = append(m.history, *current)
+	}
+	entry := m.related[n].Entry
+	m.viewing = &entry
+	return m.renderPreview(entry)
+}
+
+// cycleDiff diffs the previewed entry against each other installed version
+// of its docset in turn, then returns to the entry itself
+func (m Model) cycleDiff() (Model, tea.Cmd) {
+	entry := m.previewEntry()
+	if entry == nil || m.app == nil {
+		return m, nil
+	}
+
+	var current string
+	var others []string
+	for _, ds := range m.docsets {
+		if ds.Name != entry.Docset {
+			continue
+		}
+		if ds.Version == entry.Version {
+			current = ds.Slug
+		} else {
+			others = append(others, ds.Slug)
+		}
+	}
+	if current == "" || len(others) == 0 {
+		m.statusMsg = "No other version of " + entry.Docset + " installed"
+		return m, nil
+	}
+
+	next := 0
+	if m.diffSlug != "" {
+		next = slices.Index(others, m.diffSlug) + 1
+	}
+	if next >= len(others) {
+		m.statusMsg = ""
+		return m.renderPreview(*entry), nil
+	}
+	other := others[next]
+
+	// Whatever was loading for the preview is no longer wanted, and a
+	// diff still loading for an earlier version is ignored
+	m.previewSeq++
+	m.previewWant = nil
+	m.relatedWant = nil
+	m.diffSlug = other
+	m.statusMsg = "Loading diff against " + other + "..."
+	return m, m.loadDiff(current, other, *entry)
+}
+
+// loadDiff compares an entry between two installed versions in the
+// background. The entry is looked up in the current version, where its
+// path is known to exist, and the pair is then ordered oldest first, so
+// an upgrade always reads as additions.
+func (m Model) loadDiff(current, other string, entry model.Entry) tea.Cmd {
+	application, seq := m.app, m.previewSeq
+	return func() tea.Msg {
+		cur, oth, err := application.CompareEntry(current, other, entry.Path)
+		msg := diffLoadedMsg{seq: seq, symbol: entry.Symbol, other: other, err: err,
+			fromSlug: current, toSlug: other, from: cur, to: oth}
+
+		_, currentVersion := model.ParseSlug(current)
+		_, otherVersion := model.ParseSlug(other)
+		if model.CompareVersions(otherVersion, currentVersion) < 0 {
+			msg.fromSlug, msg.toSlug, msg.from, msg.to = other, current, oth, cur
+		}
+		return msg
+	}
+}
 
+// showDiff renders a loaded comparison into the preview pane
+func (m Model) showDiff(msg diffLoadedMsg) Model {
+	if msg.err != nil {
+		m.statusMsg = "Error: " + msg.err.Error()
+		return m
+	}
+
+	m.diff = &msg
+	m = m.renderDiff()
+	m.preview.GotoTop()
+	m.headingLines = nil
+	m.related = nil
+	m.relatedLoading = false
+	m.statusMsg = "Diff against " + msg.other + " (v: next version, V: side by side)"
+	return m
+}
+
+// renderDiff draws the shown comparison, unified or in two columns across
+// the preview's width
+func (m Model) renderDiff() Model {
+	lines := diff.Lines(m.diff.from.Content, m.diff.to.Content)
+	if !diff.Changed(lines) {
+		m.preview.SetContent(fmt.Sprintf("%s is unchanged between %s and %s", m.diff.symbol, m.diff.fromSlug, m.diff.toSlug))
+		return m
+	}
+
+	var b strings.Builder
+	opts := diff.Options{Color: m.theme != "notty", Width: m.preview.Width}
+	fromName := m.diff.fromSlug + "/" + m.diff.from.Path
+	toName := m.diff.toSlug + "/" + m.diff.to.Path
+	if m.diffSideBySide {
+		diff.SideBySide(&b, fromName, toName, diff.Hunks(lines, 3), opts)
+	} else {
+		diff.Unified(&b, fromName, toName, diff.Hunks(lines, 3), opts)
+	}
+	m.preview.SetContent(b.String())
+	return m
+}
+
+// toggleRelated opens or collapses the related entries section
+func (m Model) toggleRelated() Model {
+	m.showRelated = !m.showRelated
+	m = m.updateDimensions()
+	if entry := m.previewEntry(); entry != nil {
+		m = m.requestRelated(*entry)
+	}
 	return m
 }
 
 func (m Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	// "]]" and "[[" jump between headings
+	pending := m.pendingKey
+	m.pendingKey = ""
+	switch keyStr := msg.String(); keyStr {
+	case "]", "[":
+		if pending != keyStr {
+			m.pendingKey = keyStr
+			return m, nil
+		}
+		if keyStr == "]" {
+			m = m.jumpHeading(1)
+		} else {
+			m = m.jumpHeading(-1)
+		}
+		return m, nil
+	}
+
 	switch {
 	case key.Matches(msg, keys.Quit):
 		return m, tea.Quit
@@ -228,7 +748,9 @@ func (m Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.searchActive = false
 			m.searchInput.SetValue("")
 			if ds := m.currentDocset(); ds != nil && m.app != nil {
-				cmds = append(cmds, m.loadEntries(ds.Name, ds.Version))
+				var cmd tea.Cmd
+				m, cmd = m.loadEntries(ds.Name, ds.Version)
+				cmds = append(cmds, cmd)
 			}
 			return m, tea.Batch(cmds...)
 		}
@@ -242,7 +764,7 @@ func (m Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.mode = ModeSearch
 		m.globalSearch = true
 		m.searchInput.SetValue("")
-		m.searchInput.Placeholder = "Search all docsets..."
+		m.searchInput.Placeholder = m.searchPlaceholder()
 		m.searchInput.Focus()
 		return m, nil
 
@@ -253,7 +775,7 @@ func (m Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.mode = ModeSearch
 		m.globalSearch = false
 		m.searchInput.SetValue("")
-		m.searchInput.Placeholder = "Search current docset..."
+		m.searchInput.Placeholder = m.searchPlaceholder()
 		m.searchInput.Focus()
 		return m, nil
 
@@ -289,6 +811,7 @@ func (m Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.activePane == PaneResults && m.selectedIdx < len(m.entries)-1 {
 			m.selectedIdx++
 			m = m.updatePreviewContent()
+			return m.loadMore()
 		} else if m.activePane == PanePreview {
 			m.preview.LineDown(3)
 		}
@@ -298,7 +821,9 @@ func (m Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.activePane == PaneResults && m.activeTab > 0 {
 			m.activeTab--
 			if ds := m.currentDocset(); ds != nil && m.app != nil {
-				cmds = append(cmds, m.loadEntries(ds.Name, ds.Version))
+				var cmd tea.Cmd
+				m, cmd = m.loadEntries(ds.Name, ds.Version)
+				cmds = append(cmds, cmd)
 			}
 		} else {
 			m.activePane = PaneResults
@@ -309,7 +834,9 @@ func (m Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.activePane == PaneResults && m.activeTab < len(m.docsets)-1 {
 			m.activeTab++
 			if ds := m.currentDocset(); ds != nil && m.app != nil {
-				cmds = append(cmds, m.loadEntries(ds.Name, ds.Version))
+				var cmd tea.Cmd
+				m, cmd = m.loadEntries(ds.Name, ds.Version)
+				cmds = append(cmds, cmd)
 			}
 		} else {
 			m.activePane = PanePreview
@@ -344,6 +871,7 @@ func (m Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.activePane == PaneResults {
 			if len(m.entries) > 0 {
 				m.selectedIdx = len(m.entries) - 1
+				return m.loadMore()
 			}
 		} else {
 			m.preview.GotoBottom()
@@ -365,6 +893,55 @@ func (m Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, keys.Enter):
 		m.activePane = PanePreview
 		return m, nil
+
+	case key.Matches(msg, keys.Copy):
+		if m.examples != nil && m.selectedIdx < len(m.examples) {
+			if err := clipboard.WriteAll(m.examples[m.selectedIdx].Code); err != nil {
+				m.statusMsg = "Error: " + err.Error()
+			} else {
+				m.statusMsg = "Copied example to clipboard"
+			}
+		}
+		return m, nil
+
+	case key.Matches(msg, keys.Outline):
+		if len(m.headings) > 0 {
+			m.mode = ModeOutline
+			m.outlineIdx = m.currentHeading()
+		}
+		return m, nil
+
+	case key.Matches(msg, keys.Diff):
+		return m.cycleDiff()
+
+	case key.Matches(msg, keys.DiffLayout):
+		m.diffSideBySide = !m.diffSideBySide
+		if m.diff != nil {
+			m = m.renderDiff()
+		}
+		return m, nil
+
+	case key.Matches(msg, keys.Related):
+		return m.toggleRelated(), nil
+
+	case key.Matches(msg, keys.OpenRelated):
+		if m.showRelated {
+			m = m.openRelated(int(msg.String()[0] - '1'))
+		}
+		return m, nil
+
+	case key.Matches(msg, keys.Back):
+		// Step back through opened related entries
+		if len(m.history) > 0 {
+			entry := m.history[len(m.history)-1]
+			m.history = m.history[:len(m.history)-1]
+			m.viewing = nil
+			if len(m.history) > 0 {
+				m.viewing = &entry
+			}
+			m = m.renderPreview(entry)
+		}
+		return m, nil
 	}
 
 	return m, nil
@@ -383,9 +960,12 @@ func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Reload entries without filter
 		if query != "" {
 			if ds := m.currentDocset(); ds != nil && m.app != nil {
-				cmds = append(cmds, m.loadEntries(ds.Name, ds.Version))
+				var cmd tea.Cmd
+				m, cmd = m.loadEntries(ds.Name, ds.Version)
+				cmds = append(cmds, cmd)
 			} else {
 				m.entries = nil
+				m.total = 0
 			}
 		}
 		return m, tea.Batch(cmds...)
@@ -410,8 +990,28 @@ func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Navigate results while searching
 		if m.selectedIdx < len(m.entries)-1 {
 			m.selectedIdx++
+			return m.loadMore()
 		}
 		return m, nil
+
+	case key.Matches(msg, keys.Tab) && m.suggestion != "":
+		// Accept the "did you mean" suggestion
+		m.searchInput.SetValue(m.suggestion)
+		m.searchInput.CursorEnd()
+		m.suggestion = ""
+		return m.runSearch()
+
+	case key.Matches(msg, keys.FuzzyToggle):
+		m.fuzzySearch = !m.fuzzySearch
+		m.codeSearch = false
+		m.searchInput.Placeholder = m.searchPlaceholder()
+		return m.runSearch()
+
+	case key.Matches(msg, keys.CodeToggle):
+		m.codeSearch = !m.codeSearch
+		m.fuzzySearch = false
+		m.searchInput.Placeholder = m.searchPlaceholder()
+		return m.runSearch()
 	}
 
 	// Pass other keys to the text input
@@ -420,21 +1020,54 @@ func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	cmds = append(cmds, cmd)
 
 	// Real-time search
+	m, cmd = m.runSearch()
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+// runSearch searches for the current input using the active search mode,
+// or reloads the current docset when the input is empty. Replies to
+// searches started before it are dropped.
+func (m Model) runSearch() (Model, tea.Cmd) {
+	if m.app == nil {
+		return m, nil
+	}
+
 	query := m.searchInput.Value()
-	if query != "" && m.app != nil {
-		if m.globalSearch {
-			cmds = append(cmds, m.doGlobalSearch(query))
-		} else {
-			cmds = append(cmds, m.doLocalSearch(query))
-		}
-	} else if m.app != nil {
-		// Empty query - reload current docset entries
+	if query == "" {
 		if ds := m.currentDocset(); ds != nil {
-			cmds = append(cmds, m.loadEntries(ds.Name, ds.Version))
+			return m.loadEntries(ds.Name, ds.Version)
 		}
+		return m, nil
 	}
 
-	return m, tea.Batch(cmds...)
+	m.searchSeq++
+	switch {
+	case m.fuzzySearch:
+		return m, m.doFuzzySearch(query)
+	case m.codeSearch:
+		return m, m.doCodeSearch(query)
+	case m.globalSearch:
+		return m, m.doGlobalSearch(query)
+	default:
+		return m, m.doLocalSearch(query)
+	}
+}
+
+// searchPlaceholder describes the active search scope and mode
+func (m Model) searchPlaceholder() string {
+	scope := "current docset"
+	if m.globalSearch {
+		scope = "all docsets"
+	}
+	if m.fuzzySearch {
+		return "Fuzzy match symbols in " + scope + "..."
+	}
+	if m.codeSearch {
+		return "Search code examples in " + scope + "..."
+	}
+	return "Search " + scope + "..."
 }
 
 func (m Model) updateHelp(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -446,6 +1079,66 @@ func (m Model) updateHelp(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m Model) updateOutline(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Escape), key.Matches(msg, keys.Outline), key.Matches(msg, keys.Quit):
+		m.mode = ModeNormal
+
+	case key.Matches(msg, keys.Up):
+		if m.outlineIdx > 0 {
+			m.outlineIdx--
+		}
+
+	case key.Matches(msg, keys.Down):
+		if m.outlineIdx < len(m.headings)-1 {
+			m.outlineIdx++
+		}
+
+	case key.Matches(msg, keys.Enter):
+		if m.outlineIdx < len(m.headingLines) && m.headingLines[m.outlineIdx] >= 0 {
+			m.preview.SetYOffset(m.headingLines[m.outlineIdx])
+			m.activePane = PanePreview
+		}
+		m.mode = ModeNormal
+	}
+	return m, nil
+}
+
+// currentHeading returns the index of the last heading at or above the
+// top of the preview
+func (m Model) currentHeading() int {
+	current := 0
+	for i, line := range m.headingLines {
+		if line >= 0 && line <= m.preview.YOffset {
+			current = i
+		}
+	}
+	return current
+}
+
+// jumpHeading scrolls the preview to the next (dir 1) or previous (dir -1)
+// heading
+func (m Model) jumpHeading(dir int) Model {
+	offset := m.preview.YOffset
+	target := -1
+	for _, line := range m.headingLines {
+		if line < 0 {
+			continue
+		}
+		if dir > 0 && line > offset {
+			target = line
+			break
+		}
+		if dir < 0 && line < offset {
+			target = line
+		}
+	}
+	if target >= 0 {
+		m.preview.SetYOffset(target)
+	}
+	return m
+}
+
 func (m Model) updateDocsetPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
@@ -508,7 +1201,7 @@ func (m Model) updateDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if err := m.app.RemoveDocset(ds.Slug); err == nil {
 				m.statusMsg = "Deleted " + ds.Slug
 				// Reload docsets
-				docsets, _ := m.app.ListInstalledDocsets()
+				docsets, _ := m.app.ScopedDocsets()
 				m.docsets = docsets
 				if m.activeTab >= len(m.docsets) {
 					m.activeTab = len(m.docsets) - 1
@@ -518,9 +1211,12 @@ func (m Model) updateDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				}
 				// Load entries for new active docset
 				if ds := m.currentDocset(); ds != nil {
-					cmds = append(cmds, m.loadEntries(ds.Name, ds.Version))
+					var cmd tea.Cmd
+					m, cmd = m.loadEntries(ds.Name, ds.Version)
+					cmds = append(cmds, cmd)
 				} else {
 					m.entries = nil
+					m.total = 0
 				}
 			} else {
 				m.statusMsg = "Error: " + err.Error()
@@ -540,6 +1236,43 @@ func (m Model) updateDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
+func (m Model) updateInstallPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.mode = ModeNormal
+		mark := m.markOffered()
+		m.offerDir = ""
+		m, cmd := m.startQueuedInstall()
+		return m, tea.Batch(cmd, mark)
+
+	case "n", "N", "esc":
+		m.mode = ModeNormal
+		m.installQueue = nil
+		m.statusMsg = m.installHint
+		mark := m.markOffered()
+		m.offerDir = ""
+		return m, mark
+
+	default:
+		return m, nil
+	}
+}
+
+// startQueuedInstall starts downloading the next docset of the install
+// queue
+func (m Model) startQueuedInstall() (Model, tea.Cmd) {
+	slug := m.installQueue[0]
+	m.installQueue = m.installQueue[1:]
+
+	m.downloading = true
+	m.downloadSlug = slug
+	m.downloadPct = 0
+	m.downloadStatus = "Starting..."
+	return m, func() tea.Msg {
+		return startDownloadMsg{slug: slug}
+	}
+}
+
 // filteredManifest returns manifest entries matching the picker search
 func (m Model) filteredManifest() []model.ManifestEntry {
 	query := strings.ToLower(m.pickerSearch.Value())
@@ -560,62 +1293,163 @@ func (m Model) filteredManifest() []model.ManifestEntry {
 // Commands
 
 func (m Model) doSearch(query string) tea.Cmd {
+	source := resultSource{query: query}
+	if ds := m.currentDocset(); ds != nil {
+		source.docset = ds.Name
+		source.version = ds.Version
+	}
+	return m.searchPage(source, 0)
+}
+
+func (m Model) doGlobalSearch(query string) tea.Cmd {
+	// Search across ALL docsets (empty docset/version = global)
+	return m.searchPage(resultSource{query: query}, 0)
+}
+
+func (m Model) doLocalSearch(query string) tea.Cmd {
+	ds := m.currentDocset()
+	if ds == nil {
+		return nil
+	}
+
+	// Search only current docset
+	return m.searchPage(resultSource{query: query, docset: ds.Name, version: ds.Version}, 0)
+}
+
+func (m Model) doCodeSearch(query string) tea.Cmd {
+	source := resultSource{query: query, code: true}
+	if !m.globalSearch {
+		ds := m.currentDocset()
+		if ds == nil {
+			return nil
+		}
+		source.docset = ds.Name
+		source.version = ds.Version
+	}
+	return m.searchPage(source, 0)
+}
+
+// searchPage fetches a page of search results starting at offset. The
+// first page also counts the total number of matches.
+func (m Model) searchPage(source resultSource, offset int) tea.Cmd {
+	if source.code {
+		return m.examplePage(source, offset)
+	}
+
+	seq := m.searchSeq
 	return func() tea.Msg {
 		if m.app == nil {
-			return searchResultsMsg{err: nil}
+			return searchResultsMsg{}
 		}
 
-		ds := m.currentDocset()
-		docset := ""
-		version := ""
-		if ds != nil {
-			docset = ds.Name
-			version = ds.Version
+		results, err := m.app.Search(source.query, source.docset, source.version, pageSize, offset)
+		if err != nil {
+			return searchResultsMsg{seq: seq, source: source, offset: offset, err: err}
+		}
+
+		if offset > 0 {
+			return searchResultsMsg{seq: seq, source: source, results: results, offset: offset}
+		}
+
+		total, err := m.app.CountResults(source.query, source.docset, source.version)
+		if err != nil {
+			return searchResultsMsg{seq: seq, source: source, err: err}
 		}
 
-		results, err := m.app.Search(query, docset, version, 100)
-		return searchResultsMsg{results: results, err: err}
+		// Offer a respelling when the query finds next to nothing
+		var suggestion string
+		if total < app.SuggestBelow {
+			suggestion, _ = m.app.Suggest(source.query, source.docset, source.version)
+		}
+		return searchResultsMsg{seq: seq, source: source, results: results, total: total, suggestion: suggestion}
 	}
 }
 
-func (m Model) doGlobalSearch(query string) tea.Cmd {
+// examplePage fetches a page of code examples starting at offset
+func (m Model) examplePage(source resultSource, offset int) tea.Cmd {
+	seq := m.searchSeq
 	return func() tea.Msg {
 		if m.app == nil {
-			return searchResultsMsg{err: nil}
+			return exampleResultsMsg{}
+		}
+
+		examples, err := m.app.SearchExamples(source.query, source.docset, source.version, pageSize, offset)
+		if err != nil || offset > 0 {
+			return exampleResultsMsg{seq: seq, source: source, examples: examples, offset: offset, err: err}
 		}
 
-		// Search across ALL docsets (empty docset/version = global)
-		results, err := m.app.Search(query, "", "", 100)
-		return searchResultsMsg{results: results, err: err}
+		total, err := m.app.CountExamples(source.query, source.docset, source.version)
+		return exampleResultsMsg{seq: seq, source: source, examples: examples, total: total, err: err}
 	}
 }
 
-func (m Model) doLocalSearch(query string) tea.Cmd {
+func (m Model) doFuzzySearch(query string) tea.Cmd {
+	seq := m.searchSeq
 	return func() tea.Msg {
 		if m.app == nil {
-			return searchResultsMsg{err: nil}
+			return fuzzyResultsMsg{}
 		}
 
-		ds := m.currentDocset()
-		if ds == nil {
-			return searchResultsMsg{err: nil}
+		// Match symbols in every docset for global search, else the current one
+		docsets := m.docsets
+		if !m.globalSearch {
+			ds := m.currentDocset()
+			if ds == nil {
+				return fuzzyResultsMsg{seq: seq}
+			}
+			docsets = []model.Docset{*ds}
 		}
 
-		// Search only current docset
-		results, err := m.app.Search(query, ds.Name, ds.Version, 100)
-		return searchResultsMsg{results: results, err: err}
+		results, err := m.app.FuzzySearch(query, docsets, 100)
+		return fuzzyResultsMsg{seq: seq, results: results, err: err}
 	}
 }
 
-func (m Model) loadEntries(docset, version string) tea.Cmd {
+// loadEntries lists a docset in the results pane, superseding any search
+// or listing still loading
+func (m Model) loadEntries(docset, version string) (Model, tea.Cmd) {
+	m.searchSeq++
+	return m, m.listPage(resultSource{docset: docset, version: version}, nil)
+}
+
+// listPage fetches a page of a docset's entries after the given one. The
+// first page also counts the docset's entries.
+func (m Model) listPage(source resultSource, after *model.Entry) tea.Cmd {
+	seq := m.searchSeq
 	return func() tea.Msg {
 		if m.app == nil {
-			return entriesLoadedMsg{err: nil}
+			return entriesLoadedMsg{}
+		}
+
+		entries, err := m.app.ListEntries(source.docset, source.version, after, pageSize)
+		if err != nil {
+			return entriesLoadedMsg{seq: seq, source: source, more: after != nil, err: err}
 		}
 
-		entries, err := m.app.ListEntries(docset, version, 100)
-		return entriesLoadedMsg{entries: entries, err: err}
+		total := 0
+		if after == nil {
+			total, err = m.app.CountEntries(source.docset, source.version)
+		}
+		return entriesLoadedMsg{seq: seq, source: source, entries: entries, total: total, more: after != nil, err: err}
+	}
+}
+
+// loadMore fetches the next page of results once the selection nears the
+// end of what has been loaded
+func (m Model) loadMore() (Model, tea.Cmd) {
+	if m.app == nil || m.loadingMore || len(m.entries) >= m.total {
+		return m, nil
 	}
+	if m.selectedIdx < len(m.entries)-loadMoreThreshold {
+		return m, nil
+	}
+
+	m.loadingMore = true
+	if m.source.query != "" {
+		return m, m.searchPage(m.source, len(m.entries))
+	}
+	last := m.entries[len(m.entries)-1]
+	return m, m.listPage(m.source, &last)
 }
 
 func (m Model) loadManifest() tea.Cmd {
@@ -629,6 +1463,35 @@ func (m Model) loadManifest() tea.Cmd {
 	}
 }
 
+// offerDetectedDocsets detects the docsets of the project in the working
+// directory, the first time lazydocs runs there
+func (m Model) offerDetectedDocsets() tea.Cmd {
+	return func() tea.Msg {
+		wd, err := os.Getwd()
+		if err != nil {
+			return detectedDocsetsMsg{}
+		}
+		// Detection is a convenience; errors such as being offline just
+		// mean no offer
+		suggestions, _ := m.app.OfferDocsets(wd)
+		return detectedDocsetsMsg{dir: wd, suggestions: suggestions}
+	}
+}
+
+// markOffered records that the detected docsets were offered, so the next
+// launch doesn't ask again; nil when the prompt wasn't a detection offer
+func (m Model) markOffered() tea.Cmd {
+	dir := m.offerDir
+	if dir == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		// Failing to record just means being asked again
+		m.app.MarkOffered(dir)
+		return nil
+	}
+}
+
 func (m Model) installDocset(slug string) tea.Cmd {
 	return func() tea.Msg {
 		if m.app == nil {
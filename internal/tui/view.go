@@ -2,9 +2,11 @@ package tui
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
 )
 
 // View implements tea.Model
@@ -20,6 +22,8 @@ func (m Model) View() string {
 		content = m.viewHelp()
 	case ModeDocsetPicker:
 		content = m.viewDocsetPicker()
+	case ModeOutline:
+		content = m.viewOutline()
 	default:
 		content = m.viewMain()
 	}
@@ -134,18 +138,33 @@ func (m Model) viewResults(width, height int) string {
 	// Title - show search type
 	title := "Results"
 	if m.mode == ModeSearch && m.searchInput.Value() != "" {
-		if m.globalSearch {
+		switch {
+		case m.fuzzySearch:
+			title = "Fuzzy: " + m.searchInput.Value()
+		case m.codeSearch:
+			title = "Code: " + m.searchInput.Value()
+		case m.globalSearch:
 			title = "Global: " + m.searchInput.Value()
-		} else {
+		default:
 			title = "Search: " + m.searchInput.Value()
 		}
 	}
-	lines = append(lines, titleStyle.Render(title))
+	title = titleStyle.Render(title)
+	if m.total > 0 {
+		title += helpStyle.Render(fmt.Sprintf(" %s/%s", formatCount(m.selectedIdx+1), formatCount(m.total)))
+	}
+	lines = append(lines, title)
 	lines = append(lines, "")
 
 	// Show search input if in search mode
 	if m.mode == ModeSearch {
 		lines = append(lines, m.searchInput.View())
+		if m.searchErr != "" {
+			lines = append(lines, errorStyle.Render(truncate.StringWithTail(m.searchErr, uint(width), "...")))
+		} else if m.suggestion != "" {
+			line := helpStyle.Render("Did you mean ") + matchStyle.Render(m.suggestion) + helpStyle.Render("? (tab)")
+			lines = append(lines, truncate.StringWithTail(line, uint(width), "..."))
+		}
 		lines = append(lines, "")
 	}
 
@@ -158,8 +177,11 @@ func (m Model) viewResults(width, height int) string {
 		return strings.Join(lines, "\n")
 	}
 
-	// List entries
+	// List entries; search hits take a second line for their snippet
 	visibleCount := height - 4
+	if m.snippets != nil {
+		visibleCount /= 2
+	}
 	if visibleCount < 1 {
 		visibleCount = 1
 	}
@@ -177,6 +199,8 @@ func (m Model) viewResults(width, height int) string {
 		line := entry.Symbol
 		if line == "" {
 			line = entry.Title
+		} else if i < len(m.matches) {
+			line = highlightRunes(line, m.matches[i])
 		}
 
 		// Show docset name for global search results
@@ -197,21 +221,83 @@ func (m Model) viewResults(width, height int) string {
 
 		// Truncate if needed
 		if lipgloss.Width(line) > width {
-			line = line[:width-3] + "..."
+			line = truncate.StringWithTail(line, uint(width), "...")
 		}
 
 		lines = append(lines, line)
+
+		if m.snippets != nil {
+			snippet := ""
+			if i < len(m.snippets) {
+				snippet = "    " + m.snippets[i]
+			}
+			if lipgloss.Width(snippet) > width {
+				snippet = truncate.StringWithTail(snippet, uint(width), "...")
+			}
+			lines = append(lines, snippet)
+		}
 	}
 
 	return strings.Join(lines, "\n")
 }
 
+// highlightRunes styles the runes of s at the given offsets as matches
+func highlightRunes(s string, positions []int) string {
+	if len(positions) == 0 {
+		return s
+	}
+
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		matched[p] = true
+	}
+
+	var b strings.Builder
+	for i, r := range []rune(s) {
+		if matched[i] {
+			b.WriteString(matchStyle.Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
 func (m Model) viewPreview(width, height int) string {
 	if len(m.entries) == 0 || m.selectedIdx >= len(m.entries) {
 		return "No entry selected"
 	}
 
-	return m.preview.View()
+	if !m.showRelated {
+		return m.preview.View()
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, m.preview.View(), m.viewRelated(width))
+}
+
+// viewRelated renders the related entries section below the preview
+func (m Model) viewRelated(width int) string {
+	lines := []string{
+		helpStyle.Render(strings.Repeat("─", width)),
+		titleStyle.Render("Related") + helpStyle.Render(" (1-5 to open, r to hide)"),
+	}
+
+	if m.relatedLoading {
+		lines = append(lines, helpStyle.Render("  Loading..."))
+	} else if len(m.related) == 0 {
+		lines = append(lines, helpStyle.Render("  No related entries"))
+	}
+	for i, r := range m.related {
+		line := fmt.Sprintf("%d %s", i+1, r.Symbol)
+		if r.Type != "" {
+			line += " " + helpStyle.Render(r.Type)
+		}
+		if lipgloss.Width(line) > width {
+			line = truncate.StringWithTail(line, uint(width), "...")
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
 }
 
 func (m Model) viewStatusBar() string {
@@ -234,7 +320,7 @@ func (m Model) viewStatusBar() string {
 	// Debug: show mode (only if enabled in config)
 	debugInfo := ""
 	if m.showDebug {
-		modeNames := []string{"NORMAL", "SEARCH", "HELP", "PICKER", "DELETE?"}
+		modeNames := []string{"NORMAL", "SEARCH", "HELP", "PICKER", "DELETE?", "OUTLINE", "INSTALL?"}
 		modeName := "?"
 		if int(m.mode) < len(modeNames) {
 			modeName = modeNames[m.mode]
@@ -293,6 +379,17 @@ func (m Model) viewHelp() string {
  ──────────────────────────────────────
  s             Search current docset
  /             Search all docsets (global)
+ Ctrl+t        Toggle fuzzy symbol search
+ Ctrl+x        Toggle code example search
+ Tab           Accept "did you mean" suggestion
+ y             Copy the selected code example
+ o             Outline of the current entry
+ ]] / [[       Next / previous heading
+ v             Diff with other installed versions
+ V             Toggle side-by-side diff
+ r             Toggle related entries
+ 1-5           Open a related entry
+ Backspace     Back from a related entry
  a             Add docset
  d             Delete selected docset
  u             Update selected docset
@@ -300,6 +397,16 @@ func (m Model) viewHelp() string {
  q, Ctrl+c     Quit
  Esc           Close modal / clear search
 
+ Search syntax
+ ──────────────────────────────────────
+ "a phrase"    Match words in order
+ -word         Exclude a word
+ a OR b        Match either side
+ symbol:word   Match within symbols
+ docset:rails  Filter by docset[~version]
+ version:7.1   Filter by version
+ type:method   Filter by entry type
+
  Press ? or Esc to close this help
 `
 	helpStyle := lipgloss.NewStyle().
@@ -318,6 +425,46 @@ func (m Model) viewHelp() string {
 	return centered
 }
 
+func (m Model) viewOutline() string {
+	var content strings.Builder
+
+	content.WriteString(" Outline\n")
+	content.WriteString("─────────────────────────────────────────────\n\n")
+
+	// Show up to 20 headings
+	maxVisible := 20
+	start := 0
+	if m.outlineIdx >= maxVisible {
+		start = m.outlineIdx - maxVisible + 1
+	}
+
+	for i := start; i < len(m.headings) && i < start+maxVisible; i++ {
+		h := m.headings[i]
+		line := strings.Repeat("  ", h.Level-1) + h.Text
+		line = truncate.StringWithTail(line, 52, "...")
+		if i == m.outlineIdx {
+			content.WriteString(" " + selectedItemStyle.Render("> "+line) + "\n")
+		} else {
+			content.WriteString("   " + line + "\n")
+		}
+	}
+
+	content.WriteString("\n─────────────────────────────────────────────\n")
+	content.WriteString(" Enter: jump  Esc: close")
+
+	outlineStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 2).
+		Width(60)
+
+	return lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		outlineStyle.Render(content.String()),
+	)
+}
+
 func (m Model) viewDocsetPicker() string {
 	var content strings.Builder
 
@@ -348,7 +495,9 @@ func (m Model) viewDocsetPicker() string {
 	filtered := m.filteredManifest()
 
 	if len(filtered) == 0 {
-		if len(m.availableDocsets) == 0 {
+		if m.pickerErr != nil {
+			content.WriteString(" " + m.pickerErr.Error() + "\n")
+		} else if len(m.availableDocsets) == 0 {
 			content.WriteString(" Loading docsets...\n")
 		} else {
 			content.WriteString(" No matching docsets\n")
@@ -406,3 +555,12 @@ func (m Model) viewDocsetPicker() string {
 
 	return centered
 }
+
+// formatCount formats n with thousands separators
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}